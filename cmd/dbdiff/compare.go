@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ygrebnov/dbdiff/dbdiff"
+)
+
+// Databases comparison commands names.
+const (
+	defaultCommand = "dbdiff"
+	schemaCommand  = "schema"
+	dataCommand    = "data"
+)
+
+// runCompare compares databases according to given command line arguments. A given command name defines compared
+// parts: schemas only, data only, or both of them for the default command.
+func runCompare(command string, args []string) {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	displayHelp := fs.Bool("h", false, "Display help")
+	displayVersion, asFiles := new(bool), new(bool)
+	if command == defaultCommand {
+		displayVersion = fs.Bool("version", false, "Display version")
+		asFiles = fs.Bool("f", false, "Compare databases as files")
+	}
+	options := newComparisonOptions(fs)
+	stream := fs.Bool("stream", false, "Output tables comparison results as soon as they are obtained")
+	emitSchemaSQL, schemaSQLDestructive, emitDataSQL := new(string), new(bool), new(string)
+	if command != dataCommand {
+		emitSchemaSQL = fs.String("emit-schema-sql", "", "Path of a file statements turning database2 schema into database1 one are written to")
+		schemaSQLDestructive = fs.Bool("schema-sql-destructive", false, "Emit statements which may lose data uncommented")
+	}
+	if command != schemaCommand {
+		emitDataSQL = fs.String("emit-data-sql", "", "Path of a file statements turning database2 data into database1 one are written to")
+	}
+	baseline := fs.String("baseline", "", "Path of a file listing accepted differences")
+	updateBaseline := fs.Bool("update-baseline", false, "Rewrite the baseline file with differences found by the comparison")
+	targetsFile := fs.String("targets", "", "Path of a file listing target databases compared with database1, one per line")
+	parallelTargets := fs.Int("parallel-targets", dbdiff.DefaultParallelTargets, "Number of target databases compared concurrently")
+	_ = fs.Parse(args)
+
+	if *displayHelp {
+		fmt.Println(commandHelps[command])
+		os.Exit(0)
+	}
+
+	if *displayVersion {
+		printVersion()
+		os.Exit(0)
+	}
+
+	c := options.configure(fs)
+
+	// database1 is compared with each of target databases, if more than one is specified
+	args = fs.Args()
+	if len(*targetsFile) > 0 {
+		targets, err := readTargets(*targetsFile)
+		if err != nil {
+			log.Fatalln("cannot read targets file:", err)
+		}
+		args = append(args, targets...)
+	}
+	many := len(args) > 2 || len(*targetsFile) > 0
+	if len(args) < 2 || (many && *asFiles) {
+		log.Fatal(commandUsages[command])
+	}
+	// files are compared by their paths
+	if !*asFiles {
+		args = c.databases(args)
+	}
+
+	ctx := options.context(context.Background())
+	ctx = context.WithValue(ctx, dbdiff.SchemaOnlyContextKey, command == schemaCommand)
+	ctx = context.WithValue(ctx, dbdiff.DataOnlyContextKey, command == dataCommand)
+	ctx = context.WithValue(ctx, dbdiff.StreamContextKey, *stream)
+	ctx = context.WithValue(ctx, dbdiff.EmitSchemaSQLContextKey, *emitSchemaSQL)
+	ctx = context.WithValue(ctx, dbdiff.SchemaSQLDestructiveContextKey, *schemaSQLDestructive)
+	ctx = context.WithValue(ctx, dbdiff.EmitDataSQLContextKey, *emitDataSQL)
+	ctx = context.WithValue(ctx, dbdiff.BaselineContextKey, *baseline)
+	ctx = context.WithValue(ctx, dbdiff.UpdateBaselineContextKey, *updateBaseline)
+	ctx = context.WithValue(ctx, dbdiff.ParallelTargetsContextKey, *parallelTargets)
+
+	ctx, stop := options.runContext(ctx)
+	defer stop()
+
+	var differ bool
+	switch {
+	case many:
+		differ = dbdiff.NewMultiDatabaseComparer().CompareMany(ctx, args[0], args[1:])
+	case *asFiles:
+		differ = dbdiff.NewFileComparer().Compare(ctx, args[0], args[1])
+	default:
+		differ = dbdiff.NewDatabaseComparer().Compare(ctx, args[0], args[1])
+	}
+
+	if err := ctx.Err(); err != nil {
+		stop()
+		log.Fatalln("comparison interrupted, results are incomplete:", err)
+	}
+	// differences accepted by the baseline, if any, do not make databases different
+	if differ && !*updateBaseline {
+		stop()
+		os.Exit(1)
+	}
+}
+
+// runFiles compares two files line by line according to given command line arguments.
+func runFiles(args []string) {
+	fs := flag.NewFlagSet("files", flag.ExitOnError)
+	displayHelp := fs.Bool("h", false, "Display help")
+	_ = fs.Parse(args)
+
+	if *displayHelp {
+		fmt.Println(commandHelps["files"])
+		os.Exit(0)
+	}
+
+	if len(fs.Args()) != 2 {
+		log.Fatal(filesUsage)
+	}
+
+	ctx, stop := runContext(context.Background(), 0)
+	defer stop()
+
+	differ := dbdiff.NewFileComparer().Compare(ctx, fs.Args()[0], fs.Args()[1])
+	if err := ctx.Err(); err != nil {
+		stop()
+		log.Fatalln("comparison interrupted, results are incomplete:", err)
+	}
+	if differ {
+		stop()
+		os.Exit(1)
+	}
+}
+
+// runVersion outputs the version according to given command line arguments.
+func runVersion(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	displayHelp := fs.Bool("h", false, "Display help")
+	_ = fs.Parse(args)
+
+	if *displayHelp {
+		fmt.Println(commandHelps["version"])
+		os.Exit(0)
+	}
+
+	if len(fs.Args()) != 0 {
+		log.Fatal(versionUsage)
+	}
+
+	printVersion()
+}
+
+// printVersion outputs the version and the build timestamp.
+func printVersion() {
+	fmt.Printf("dbdiff, version: %s, built: %s\n", version, buildTime)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is a name of the configuration file looked up in the current directory.
+const defaultConfigFile = "dbdiff.yaml"
+
+// configEnv is a name of the environment variable holding the configuration file path.
+const configEnv = "DBDIFF_CONFIG"
+
+// config holds named databases profiles and default command line options values.
+type config struct {
+	Databases map[string]profile `yaml:"databases"`
+	// Options holds default values of command line options by option name, without the leading dash.
+	Options map[string]any `yaml:"options"`
+}
+
+// profile describes a database connection. Passwords are never stored in the configuration file:
+// they are read from an environment variable or a file, or, for PostgreSQL databases, from a password file
+// (.pgpass, or the one set with PGPASSFILE environment variable) by the driver.
+type profile struct {
+	// Type is a database type: sqlite, postgres or snapshot.
+	Type string `yaml:"type"`
+	// Path is an SQLite database or a snapshot file path.
+	Path         string            `yaml:"path"`
+	Host         string            `yaml:"host"`
+	Port         int               `yaml:"port"`
+	Database     string            `yaml:"database"`
+	User         string            `yaml:"user"`
+	PasswordEnv  string            `yaml:"password_env"`
+	PasswordFile string            `yaml:"password_file"`
+	Params       map[string]string `yaml:"params"`
+}
+
+// loadConfig reads the configuration file with a given path. If the path is empty, the file set
+// with DBDIFF_CONFIG environment variable or dbdiff.yaml file in the current directory is read, if any.
+// Returns nil if there is no configuration file. Databases profiles cannot be named after commands.
+func loadConfig(path string) (*config, error) {
+	if len(path) == 0 {
+		path = os.Getenv(configEnv)
+	}
+	if len(path) == 0 {
+		path = defaultConfigFile
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// the first command line argument equal to a command name is taken as the command
+	for name := range c.Databases {
+		if _, ok := commandHelps[name]; ok && name != defaultCommand {
+			return nil, fmt.Errorf("%s: database profile name %s is a command name", path, name)
+		}
+	}
+	return c, nil
+}
+
+// applyOptions sets options of a given flag set, which are not specified on the command line, to their default
+// values from the configuration. Options which are not defined in the flag set, e.g. specific to other commands,
+// are skipped.
+func (c *config) applyOptions(fs *flag.FlagSet) error {
+	specified := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		specified[f.Name] = true
+	})
+
+	names := make([]string, 0, len(c.Options))
+	for name := range c.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := fs.Lookup(name)
+		if f == nil || specified[name] || name == "config" {
+			continue
+		}
+		values, ok := c.Options[name].([]any)
+		if !ok {
+			values = []any{c.Options[name]}
+		}
+		// a list is set item by item to repeatable options, and comma-separated to the other ones
+		if _, ok = f.Value.(*listFlag); !ok {
+			items := make([]string, len(values))
+			for i, v := range values {
+				items[i] = fmt.Sprint(v)
+			}
+			values = []any{strings.Join(items, ",")}
+		}
+		for _, v := range values {
+			if err := fs.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("invalid value of option %s in configuration: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns a database identification string, in alias=type:URI format, of a given command line argument.
+// Arguments which are not databases profiles names are returned unchanged.
+func (c *config) resolve(arg string) (string, error) {
+	if c == nil || strings.Contains(arg, ":") {
+		return arg, nil
+	}
+	p, ok := c.Databases[arg]
+	if !ok {
+		return arg, nil
+	}
+	input, err := p.input()
+	if err != nil {
+		return "", fmt.Errorf("database %s: %w", arg, err)
+	}
+	// the profile name identifies the database in output
+	return arg + "=" + input, nil
+}
+
+// input returns the profile database identification string in type:URI format.
+func (p profile) input() (string, error) {
+	switch p.Type {
+	case "sqlite", "snapshot":
+		if len(p.Path) == 0 {
+			return "", errors.New("path is not set")
+		}
+		return fmt.Sprintf("%s:%s", p.Type, p.Path), nil
+	case "postgres":
+	default:
+		return "", fmt.Errorf("unknown database type %q", p.Type)
+	}
+
+	host := p.Host
+	if len(host) == 0 {
+		host = "localhost"
+	}
+	if p.Port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(p.Port))
+	}
+	u := url.URL{Scheme: "postgres", Host: host, Path: "/" + p.Database}
+
+	password, err := p.password()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(password) > 0:
+		u.User = url.UserPassword(p.User, password)
+	case len(p.User) > 0:
+		u.User = url.User(p.User)
+	}
+
+	query := url.Values{}
+	for name, value := range p.Params {
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+	return "postgres:" + u.String(), nil
+}
+
+// password returns the profile password read from an environment variable or a file, if set.
+func (p profile) password() (string, error) {
+	switch {
+	case len(p.PasswordEnv) > 0:
+		password, ok := os.LookupEnv(p.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("password environment variable %s is not set", p.PasswordEnv)
+		}
+		return password, nil
+	case len(p.PasswordFile) > 0:
+		data, err := os.ReadFile(p.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("cannot read password file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return "", nil
+	}
+}
+
+// configure reads the configuration file with a given path, or the default one, if any, and sets unspecified
+// options of a given flag set to their default values from the configuration.
+// Returns nil if there is no configuration file.
+func configure(path string, fs *flag.FlagSet) *config {
+	c, err := loadConfig(path)
+	if err != nil {
+		log.Fatalln("cannot read configuration file:", err)
+	}
+	if c == nil {
+		return nil
+	}
+	if err = c.applyOptions(fs); err != nil {
+		log.Fatalln(err)
+	}
+	return c
+}
+
+// databases returns given command line arguments with databases profiles names replaced by
+// databases identification strings.
+func (c *config) databases(args []string) []string {
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		var err error
+		if resolved[i], err = c.resolve(arg); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	return resolved
+}
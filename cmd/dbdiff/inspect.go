@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ygrebnov/dbdiff/dbdiff"
+)
+
+// runInspect outputs a database normalized schema according to given command line arguments.
+func runInspect(args []string) {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	displayHelp := fs.Bool("h", false, "Display help")
+	options := newComparisonOptions(fs)
+	_ = fs.Parse(args)
+
+	if *displayHelp {
+		fmt.Println(commandHelps["inspect"])
+		os.Exit(0)
+	}
+
+	databases := options.configure(fs).databases(fs.Args())
+	if len(databases) != 1 {
+		log.Fatal(inspectUsage)
+	}
+
+	ctx := options.context(context.Background())
+
+	ctx, stop := options.runContext(ctx)
+	defer stop()
+
+	dbdiff.NewDatabaseInspector().Inspect(ctx, databases[0])
+
+	if err := ctx.Err(); err != nil {
+		stop()
+		log.Fatalln("inspection interrupted:", err)
+	}
+}
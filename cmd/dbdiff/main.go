@@ -1,11 +1,10 @@
 package main
 
 import (
-	"context"
-	"flag"
-	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/ygrebnov/dbdiff/dbdiff"
 )
@@ -13,44 +12,85 @@ import (
 var version, buildTime string
 
 func main() {
-	displayHelp := flag.Bool("h", false, "Display help")
-	displayVersion := flag.Bool("version", false, "Display version")
-	asFiles := flag.Bool("f", false, "Compare databases as files")
-	verbose := flag.Bool("v", false, "Level1 verbosity output")
-	vverbose := flag.Bool("vv", false, "Level2 verbosity output")
-	vvverbose := flag.Bool("vvv", false, "Level3 verbosity output")
-	flag.Parse()
-
-	if *displayHelp {
-		fmt.Println(help)
-		os.Exit(0)
-	}
+	// connection strings passwords are never logged
+	log.SetOutput(redactingWriter{os.Stderr})
 
-	if *displayVersion {
-		fmt.Printf("dbdiff, version: %s, built: %s\n", version, buildTime)
-		os.Exit(0)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case schemaCommand, dataCommand:
+			runCompare(os.Args[1], os.Args[2:])
+			return
+		case "files":
+			runFiles(os.Args[2:])
+			return
+		case "inspect":
+			runInspect(os.Args[2:])
+			return
+		case "version":
+			runVersion(os.Args[2:])
+			return
+		case "sync":
+			runSync(os.Args[2:])
+			return
+		case "snapshot":
+			runSnapshot(os.Args[2:])
+			return
+		case "three-way":
+			runThreeWay(os.Args[2:])
+			return
+		}
 	}
 
-	if len(flag.Args()) != 2 {
-		log.Fatal(usage)
+	// databases schemas and data are compared without a command
+	runCompare(defaultCommand, os.Args[1:])
+}
+
+// splitList splits a comma-separated command line option value into a slice of trimmed non-empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	// take the highest specified verbosity level
-	switch {
-	case *vvverbose:
-		*verbose = false
-		*vverbose = false
-	case *vverbose:
-		*verbose = false
+// readTargets reads a file listing target databases, one per line. Empty lines and lines starting with "#" are skipped.
+func readTargets(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 && !strings.HasPrefix(line, "#") {
+			targets = append(targets, line)
+		}
 	}
+	return targets, nil
+}
 
-	ctx := context.WithValue(context.Background(), dbdiff.VerboseContextKey, *verbose)
-	ctx = context.WithValue(ctx, dbdiff.VVerboseContextKey, *vverbose)
-	ctx = context.WithValue(ctx, dbdiff.VVVerboseContextKey, *vvverbose)
+// listFlag is a command line option which can be specified multiple times. Implements [flag.Value] interface.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// redactingWriter writes given data with passwords of connection strings redacted. Implements [io.Writer] interface.
+type redactingWriter struct {
+	w io.Writer
+}
 
-	if *asFiles {
-		dbdiff.NewFileComparer().Compare(ctx, flag.Args()[0], flag.Args()[1])
-	} else {
-		dbdiff.NewDatabaseComparer().Compare(ctx, flag.Args()[0], flag.Args()[1])
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, dbdiff.Redact(string(p))); err != nil {
+		return 0, err
 	}
+	return len(p), nil
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ygrebnov/dbdiff/dbdiff"
+)
+
+// comparisonOptions holds command line options shared by databases comparison and synchronization.
+type comparisonOptions struct {
+	verbose, vverbose, vvverbose         *bool
+	ignoreColumns, tables, excludeTables *string
+	nullAsEmpty                          *bool
+	absTolerance, relTolerance           *float64
+	timezone, timestampPrecision         *string
+	jsonColumns                          *string
+	binaryHashOnly, jsonArrays           *bool
+	parallel                             *int
+	maxConnections                       connectionsFlag
+	timeout, statementTimeout            *time.Duration
+	where                                listFlag
+	config                               *string
+}
+
+// newComparisonOptions returns a new comparisonOptions object with options defined in a given flag set.
+func newComparisonOptions(fs *flag.FlagSet) *comparisonOptions {
+	o := &comparisonOptions{
+		verbose:            fs.Bool("v", false, "Level1 verbosity output"),
+		vverbose:           fs.Bool("vv", false, "Level2 verbosity output"),
+		vvverbose:          fs.Bool("vvv", false, "Level3 verbosity output"),
+		ignoreColumns:      fs.String("ignore-columns", "", "Comma-separated glob patterns of columns excluded from comparison"),
+		tables:             fs.String("tables", "", "Comma-separated glob patterns of tables included into comparison"),
+		excludeTables:      fs.String("exclude-tables", "", "Comma-separated glob patterns of tables excluded from comparison"),
+		nullAsEmpty:        fs.Bool("null-as-empty", false, "Treat NULL values as equal to empty strings"),
+		absTolerance:       fs.Float64("abs-tolerance", 0, "Maximum absolute difference between numeric values considered equal"),
+		relTolerance:       fs.Float64("rel-tolerance", 0, "Maximum relative difference between numeric values considered equal"),
+		timezone:           fs.String("timezone", "UTC", "Time zone of timestamp values without explicit offsets"),
+		timestampPrecision: fs.String("timestamp-precision", "ns", "Precision timestamp values are truncated to: s, ms, us or ns"),
+		jsonColumns:        fs.String("json-columns", "", "Comma-separated glob patterns of columns compared as JSON documents"),
+		binaryHashOnly:     fs.Bool("blob-hash-only", false, "Compare binary values by their hashes only"),
+		jsonArrays:         fs.Bool("json-arrays", false, "Compare array values stored as JSON arrays to PostgreSQL array values"),
+		parallel:           fs.Int("parallel", dbdiff.DefaultParallel, "Number of tables which data is compared concurrently"),
+		timeout:            fs.Duration("timeout", 0, "Comparison time limit, e.g. 10m, 0 means no limit"),
+		statementTimeout:   fs.Duration("statement-timeout", 0, "Query execution time limit, e.g. 30s, 0 means no limit"),
+		config:             fs.String("config", "", "Path of the configuration file, dbdiff.yaml in the current directory by default"),
+	}
+	fs.Var(&o.where, "where", "Rows filter in format table=condition, can be specified multiple times")
+	fs.Var(&o.maxConnections, "max-connections", "Maximum number of open connections to each database, or to database1 and database2 "+
+		"separated by comma, 0 means no limit")
+	return o
+}
+
+// context returns a copy of a given context holding the options values.
+func (o *comparisonOptions) context(ctx context.Context) context.Context {
+	// take the highest specified verbosity level
+	switch {
+	case *o.vvverbose:
+		*o.verbose = false
+		*o.vverbose = false
+	case *o.vverbose:
+		*o.verbose = false
+	}
+
+	ctx = context.WithValue(ctx, dbdiff.VerboseContextKey, *o.verbose)
+	ctx = context.WithValue(ctx, dbdiff.VVerboseContextKey, *o.vverbose)
+	ctx = context.WithValue(ctx, dbdiff.VVVerboseContextKey, *o.vvverbose)
+	ctx = context.WithValue(ctx, dbdiff.IgnoreColumnsContextKey, splitList(*o.ignoreColumns))
+	ctx = context.WithValue(ctx, dbdiff.TablesContextKey, splitList(*o.tables))
+	ctx = context.WithValue(ctx, dbdiff.ExcludeTablesContextKey, splitList(*o.excludeTables))
+	ctx = context.WithValue(ctx, dbdiff.WhereContextKey, []string(o.where))
+	ctx = context.WithValue(ctx, dbdiff.NullAsEmptyContextKey, *o.nullAsEmpty)
+	ctx = context.WithValue(ctx, dbdiff.AbsToleranceContextKey, *o.absTolerance)
+	ctx = context.WithValue(ctx, dbdiff.RelToleranceContextKey, *o.relTolerance)
+	ctx = context.WithValue(ctx, dbdiff.TimezoneContextKey, *o.timezone)
+	ctx = context.WithValue(ctx, dbdiff.TimestampPrecisionContextKey, *o.timestampPrecision)
+	ctx = context.WithValue(ctx, dbdiff.JSONColumnsContextKey, splitList(*o.jsonColumns))
+	ctx = context.WithValue(ctx, dbdiff.BinaryHashOnlyContextKey, *o.binaryHashOnly)
+	ctx = context.WithValue(ctx, dbdiff.JSONArraysContextKey, *o.jsonArrays)
+	ctx = context.WithValue(ctx, dbdiff.ParallelContextKey, *o.parallel)
+	ctx = context.WithValue(ctx, dbdiff.MaxConnectionsContextKey, [2]int(o.maxConnections))
+	ctx = context.WithValue(ctx, dbdiff.StatementTimeoutContextKey, *o.statementTimeout)
+	return ctx
+}
+
+// configure reads the configuration file set with -config option, or the default one, if any, and sets options
+// of a given flag set, which are not specified on the command line, to their default values from the configuration.
+func (o *comparisonOptions) configure(fs *flag.FlagSet) *config {
+	return configure(*o.config, fs)
+}
+
+// runContext returns a copy of a given context which is canceled on interrupt or on timeout expiration, if any.
+// A repeated interrupt terminates the program immediately. The returned function releases the context resources.
+func (o *comparisonOptions) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return runContext(ctx, *o.timeout)
+}
+
+// runContext returns a copy of a given context which is canceled on interrupt or on expiration of a given timeout,
+// unless it is 0. A repeated interrupt terminates the program immediately. The returned function releases
+// the context resources.
+func runContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		return ctx, func() {
+			cancel()
+			stop()
+		}
+	}
+	return ctx, stop
+}
+
+// connectionsFlag holds maximum numbers of open connections to database1 and database2. It is set either to a single
+// number applied to both databases, or to two numbers separated by comma.
+type connectionsFlag [2]int
+
+func (c *connectionsFlag) String() string {
+	if c[0] == c[1] {
+		return strconv.Itoa(c[0])
+	}
+	return fmt.Sprintf("%d,%d", c[0], c[1])
+}
+
+func (c *connectionsFlag) Set(value string) error {
+	items := strings.Split(value, ",")
+	if len(items) > 2 {
+		return errors.New("expected a number or two numbers separated by comma")
+	}
+	for i, item := range items {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+		c[i] = n
+	}
+	if len(items) == 1 {
+		c[1] = c[0]
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ygrebnov/dbdiff/dbdiff"
+)
+
+// runSnapshot writes a database snapshot file according to given command line arguments.
+func runSnapshot(args []string) {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	displayHelp := fs.Bool("h", false, "Display help")
+	options := newComparisonOptions(fs)
+	output := fs.String("o", "", "Path of the snapshot file")
+	data := fs.Bool("data", false, "Store full data instead of rows hashes")
+	compress := fs.Bool("gzip", false, "Compress the snapshot file with gzip, implied by .gz extension")
+	_ = fs.Parse(args)
+
+	if *displayHelp {
+		fmt.Println(commandHelps["snapshot"])
+		os.Exit(0)
+	}
+
+	databases := options.configure(fs).databases(fs.Args())
+	if len(databases) != 1 || len(*output) == 0 {
+		log.Fatal(snapshotUsage)
+	}
+
+	ctx := options.context(context.Background())
+	ctx = context.WithValue(ctx, dbdiff.SnapshotDataContextKey, *data)
+	ctx = context.WithValue(ctx, dbdiff.SnapshotGzipContextKey, *compress)
+
+	ctx, stop := options.runContext(ctx)
+	defer stop()
+
+	dbdiff.NewDatabaseSnapshotter().Snapshot(ctx, databases[0], *output)
+
+	if err := ctx.Err(); err != nil {
+		stop()
+		log.Fatalln("snapshot interrupted:", err)
+	}
+}
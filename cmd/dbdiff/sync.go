@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ygrebnov/dbdiff/dbdiff"
+)
+
+// runSync synchronizes database2 with database1 according to given command line arguments.
+func runSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	displayHelp := fs.Bool("h", false, "Display help")
+	options := newComparisonOptions(fs)
+	dryRun := fs.Bool("dry-run", false, "Output statements instead of applying them")
+	assumeYes := fs.Bool("yes", false, "Apply statements without confirmation")
+	batchSize := fs.Int("batch-size", 100, "Number of statements executed at once")
+	destructive := fs.Bool("destructive", false, "Apply statements which may lose data, e.g. dropping tables or columns")
+	_ = fs.Parse(args)
+
+	if *displayHelp {
+		fmt.Println(commandHelps["sync"])
+		os.Exit(0)
+	}
+
+	if len(fs.Args()) != 2 {
+		log.Fatal(syncUsage)
+	}
+	databases := options.configure(fs).databases(fs.Args())
+
+	ctx := options.context(context.Background())
+	ctx = context.WithValue(ctx, dbdiff.DryRunContextKey, *dryRun)
+	ctx = context.WithValue(ctx, dbdiff.AssumeYesContextKey, *assumeYes)
+	ctx = context.WithValue(ctx, dbdiff.BatchSizeContextKey, *batchSize)
+	ctx = context.WithValue(ctx, dbdiff.SchemaSQLDestructiveContextKey, *destructive)
+
+	ctx, stop := options.runContext(ctx)
+	defer stop()
+
+	dbdiff.NewDatabaseSynchronizer().Sync(ctx, databases[0], databases[1])
+
+	if err := ctx.Err(); err != nil {
+		stop()
+		log.Fatalln("synchronization interrupted, uncommitted statements are rolled back:", err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ygrebnov/dbdiff/dbdiff"
+)
+
+// runThreeWay compares two databases derived from a common base one according to given command line arguments.
+func runThreeWay(args []string) {
+	fs := flag.NewFlagSet("three-way", flag.ExitOnError)
+	displayHelp := fs.Bool("h", false, "Display help")
+	options := newComparisonOptions(fs)
+	_ = fs.Parse(args)
+
+	if *displayHelp {
+		fmt.Println(commandHelps["three-way"])
+		os.Exit(0)
+	}
+
+	if len(fs.Args()) != 3 {
+		log.Fatal(threeWayUsage)
+	}
+	databases := options.configure(fs).databases(fs.Args())
+
+	ctx := options.context(context.Background())
+
+	ctx, stop := options.runContext(ctx)
+	defer stop()
+
+	conflicts := dbdiff.NewThreeWayComparer().CompareThreeWay(ctx, databases[0], databases[1], databases[2])
+
+	if err := ctx.Err(); err != nil {
+		stop()
+		log.Fatalln("comparison interrupted, results are not output:", err)
+	}
+	if conflicts {
+		stop()
+		os.Exit(1)
+	}
+}
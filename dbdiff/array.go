@@ -0,0 +1,288 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// Array and composite values are parsed into slices of elements. An element is either nil for NULL,
+// a string, or a slice of elements for nested arrays.
+
+// errInvalidLiteral is returned on parsing invalid array or composite literals.
+var errInvalidLiteral = errors.New("invalid literal")
+
+// arrayDifferences compares two array or composite values of a field element-wise and returns their differences.
+// Each difference is identified by the field name followed by 1-based element indices, e.g. "tags [2]" or "matrix [1][2]".
+// If jsonArrays is true, array values stored as JSON arrays, e.g. in SQLite text fields, are also accepted.
+// Returns false if any of the values cannot be parsed.
+func arrayDifferences(ctx context.Context, field *models.Field, kind valueKind, s1, s2 string, jsonArrays bool) ([]Difference, bool) {
+	parse := parseArray
+	if kind == compositeKind {
+		parse = parseComposite
+	}
+	elements1, err1 := parseArrayValue(s1, parse, jsonArrays && kind == arrayKind)
+	elements2, err2 := parseArrayValue(s2, parse, jsonArrays && kind == arrayKind)
+	if err1 != nil || err2 != nil {
+		return nil, false
+	}
+
+	// elements of arrays with known elements types are compared as values of the element type
+	elementField := &models.Field{Name: field.Name, FieldType: arrayElementType(field)}
+
+	var differences []Difference
+	compareElements(ctx, elementField, field.Name+" ", elements1, elements2, &differences)
+	return differences, true
+}
+
+// arrayElementType returns a type of elements of a given array field: the type of arrays declared as type[],
+// e.g. in SQLite, or the one following PostgreSQL ARRAY data type, e.g. "array int4". Elements of other arrays
+// are text.
+func arrayElementType(field *models.Field) string {
+	if strings.HasSuffix(field.FieldType, "[]") {
+		return strings.TrimSuffix(field.FieldType, "[]")
+	}
+	if elementType, _, _ := strings.Cut(field.Attrs, " "); field.FieldType == "array" && len(elementType) > 0 && elementType != "primary" {
+		return elementType
+	}
+	return "text"
+}
+
+// parseArrayValue parses an array or composite value with a given parser or as a JSON array.
+func parseArrayValue(s string, parse func(string) ([]any, error), jsonArrays bool) ([]any, error) {
+	if jsonArrays && strings.HasPrefix(strings.TrimSpace(s), "[") {
+		return parseJSONArray(s)
+	}
+	return parse(s)
+}
+
+// compareElements recursively compares elements of two arrays and adds their differences to a slice.
+func compareElements(ctx context.Context, elementField *models.Field, path string, elements1, elements2 []any, differences *[]Difference) {
+	for i := 0; i < len(elements1) || i < len(elements2); i++ {
+		elementPath := fmt.Sprintf("%s[%d]", path, i+1)
+		exists1, exists2 := i < len(elements1), i < len(elements2)
+		var e1, e2 any
+		if exists1 {
+			e1 = elements1[i]
+		}
+		if exists2 {
+			e2 = elements2[i]
+		}
+
+		if exists1 && exists2 {
+			nested1, ok1 := e1.([]any)
+			nested2, ok2 := e2.([]any)
+			if ok1 && ok2 {
+				compareElements(ctx, elementField, elementPath, nested1, nested2, differences)
+				continue
+			}
+			if !ok1 && !ok2 {
+				if equal, _ := compareValues(ctx, elementField, elementValue(e1), elementValue(e2)); equal {
+					continue
+				}
+			}
+		}
+
+		*differences = append(*differences, Difference{
+			Name:   elementPath,
+			Value1: formatElement(e1, exists1),
+			Value2: formatElement(e2, exists2),
+		})
+	}
+}
+
+// elementValue converts a scalar array element into an optional string.
+func elementValue(e any) sql.NullString {
+	s, ok := e.(string)
+	return sql.NullString{String: s, Valid: ok}
+}
+
+// formatElement returns an array element representation used in comparison output.
+// Nested arrays are output as array literals. An element which does not exist is represented as an empty string.
+func formatElement(e any, exists bool) string {
+	switch v := e.(type) {
+	case nil:
+		if !exists {
+			return ""
+		}
+		return nullValue
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatElement(item, true))
+		}
+		return "{" + strings.Join(items, ",") + "}"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// parseArray parses a PostgreSQL array literal, e.g. {a,"b c",NULL} or {{1,2},{3,4}}.
+// Optional dimensions decoration, e.g. [0:1]={1,2}, is skipped.
+func parseArray(s string) ([]any, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		if _, after, ok := strings.Cut(s, "="); ok {
+			s = after
+		}
+	}
+	elements, rest, err := parseArrayElements(s)
+	if err != nil || len(strings.TrimSpace(rest)) > 0 {
+		return nil, errInvalidLiteral
+	}
+	return elements, nil
+}
+
+// parseArrayElements parses an array literal at the beginning of a string and returns its elements and the rest of the string.
+func parseArrayElements(s string) ([]any, string, error) {
+	if !strings.HasPrefix(s, "{") {
+		return nil, s, errInvalidLiteral
+	}
+	s = strings.TrimLeft(s[1:], " ")
+	elements := []any{}
+	if strings.HasPrefix(s, "}") {
+		return elements, s[1:], nil
+	}
+
+	for {
+		var (
+			element any
+			err     error
+		)
+		switch {
+		case strings.HasPrefix(s, "{"):
+			element, s, err = parseArrayElements(s)
+		case strings.HasPrefix(s, `"`):
+			element, s, err = parseQuoted(s)
+		default:
+			i := strings.IndexAny(s, ",}")
+			if i < 0 {
+				return nil, s, errInvalidLiteral
+			}
+			token := strings.TrimSpace(s[:i])
+			if strings.EqualFold(token, "NULL") {
+				element = nil
+			} else {
+				element = token
+			}
+			s = s[i:]
+		}
+		if err != nil {
+			return nil, s, err
+		}
+		elements = append(elements, element)
+
+		s = strings.TrimLeft(s, " ")
+		switch {
+		case strings.HasPrefix(s, ","):
+			s = strings.TrimLeft(s[1:], " ")
+		case strings.HasPrefix(s, "}"):
+			return elements, s[1:], nil
+		default:
+			return nil, s, errInvalidLiteral
+		}
+	}
+}
+
+// parseComposite parses a PostgreSQL composite value literal, e.g. (1,"a b",). Empty unquoted attributes are NULL.
+func parseComposite(s string) ([]any, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return nil, errInvalidLiteral
+	}
+	s = s[1:]
+	var elements []any
+	for {
+		var (
+			element any
+			err     error
+		)
+		if strings.HasPrefix(s, `"`) {
+			element, s, err = parseQuoted(s)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			i := strings.IndexAny(s, ",)")
+			if i < 0 {
+				return nil, errInvalidLiteral
+			}
+			if i > 0 {
+				element = s[:i]
+			}
+			s = s[i:]
+		}
+		elements = append(elements, element)
+
+		switch {
+		case strings.HasPrefix(s, ","):
+			s = s[1:]
+		case s == ")":
+			return elements, nil
+		default:
+			return nil, errInvalidLiteral
+		}
+	}
+}
+
+// parseQuoted parses a double-quoted array element or composite attribute at the beginning of a string.
+// Backslash escapes and doubled double quotes are supported. Returns the element and the rest of the string.
+func parseQuoted(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == '"' && i+1 < len(s) && s[i+1] == '"':
+			i++
+			b.WriteByte('"')
+		case c == '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", s, errInvalidLiteral
+}
+
+// parseJSONArray parses a JSON array into array elements. Scalars are converted into their text representations,
+// JSON null into NULL, nested arrays into nested elements. Objects are kept as compact JSON documents.
+func parseJSONArray(s string) ([]any, error) {
+	doc, ok := decodeJSON(s)
+	if !ok {
+		return nil, errInvalidLiteral
+	}
+	items, ok := doc.([]any)
+	if !ok {
+		return nil, errInvalidLiteral
+	}
+	return jsonElements(items), nil
+}
+
+// jsonElements converts JSON array items into array elements.
+func jsonElements(items []any) []any {
+	elements := make([]any, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case nil:
+			elements = append(elements, nil)
+		case string:
+			elements = append(elements, v)
+		case []any:
+			elements = append(elements, jsonElements(v))
+		case json.Number:
+			elements = append(elements, v.String())
+		case map[string]any:
+			elements = append(elements, formatJSON(v, true))
+		default:
+			elements = append(elements, fmt.Sprintf("%v", v))
+		}
+	}
+	return elements
+}
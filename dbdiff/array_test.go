@@ -0,0 +1,180 @@
+package dbdiff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+func TestParseArray(t *testing.T) {
+	var tests = []struct {
+		name             string
+		value            string
+		expectedElements []any
+		expectedErr      error
+	}{
+		{"empty", "{}", []any{}, nil},
+		{"plain", "{a,b}", []any{"a", "b"}, nil},
+		{"quoted", `{"a b","c,d","e\"f","NULL"}`, []any{"a b", "c,d", `e"f`, "NULL"}, nil},
+		{"null", "{a,NULL,null}", []any{"a", nil, nil}, nil},
+		{"nested", "{{1,2},{3,4}}", []any{[]any{"1", "2"}, []any{"3", "4"}}, nil},
+		{"dimensions", "[0:1]={1,2}", []any{"1", "2"}, nil},
+		{"whitespace", "{ a , b }", []any{"a", "b"}, nil},
+		{"unterminated", "{a,b", nil, errInvalidLiteral},
+		{"unterminated_quote", `{"a}`, nil, errInvalidLiteral},
+		{"trailing_data", "{a}b", nil, errInvalidLiteral},
+		{"not_array", "a,b", nil, errInvalidLiteral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualElements, actualErr := parseArray(tt.value)
+			require.Equal(t, tt.expectedElements, actualElements)
+			require.Equal(t, tt.expectedErr, actualErr)
+		})
+	}
+}
+
+func TestParseComposite(t *testing.T) {
+	var tests = []struct {
+		name             string
+		value            string
+		expectedElements []any
+		expectedErr      error
+	}{
+		{"plain", "(1,a)", []any{"1", "a"}, nil},
+		{"quoted", `(1,"a ""b"", c","d\\e")`, []any{"1", `a "b", c`, `d\e`}, nil},
+		{"null", "(,a,)", []any{nil, "a", nil}, nil},
+		{"empty_string", `("",a)`, []any{"", "a"}, nil},
+		{"unterminated", "(1,a", nil, errInvalidLiteral},
+		{"trailing_data", `("a"b)`, nil, errInvalidLiteral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualElements, actualErr := parseComposite(tt.value)
+			require.Equal(t, tt.expectedElements, actualElements)
+			require.Equal(t, tt.expectedErr, actualErr)
+		})
+	}
+}
+
+func TestArrayDifferences(t *testing.T) {
+	var tests = []struct {
+		name                string
+		field               *models.Field
+		kind                valueKind
+		value1              string
+		value2              string
+		jsonArrays          bool
+		expectedDifferences []Difference
+		expectedOk          bool
+	}{
+		{"quoting", &models.Field{Name: "tags", FieldType: "array"}, arrayKind, `{a,"b"}`, `{"a",b}`, false, nil, true},
+		{
+			"elements",
+			&models.Field{Name: "tags", FieldType: "array"},
+			arrayKind,
+			"{a,b,c}",
+			"{a,NULL}",
+			false,
+			[]Difference{{Name: "tags [2]", Value1: "b", Value2: "NULL"}, {Name: "tags [3]", Value1: "c"}},
+			true,
+		},
+		{
+			"nested",
+			&models.Field{Name: "m", FieldType: "array"},
+			arrayKind,
+			"{{1,2},{3,4}}",
+			"{{1,2},{3,5},{6}}",
+			false,
+			[]Difference{{Name: "m [2][2]", Value1: "4", Value2: "5"}, {Name: "m [3]", Value2: "{6}"}},
+			true,
+		},
+		{"element_type", &models.Field{Name: "n", FieldType: "numeric[]"}, arrayKind, "{1.0,2}", "{1,2.00}", false, nil, true},
+		{
+			"postgres_element_type",
+			&models.Field{Name: "n", FieldType: "array", Attrs: "numeric"}, arrayKind, "{1.0,2}", "{1,2.00}", false, nil, true,
+		},
+		{"json_arrays", &models.Field{Name: "tags", FieldType: "array"}, arrayKind, `["a",null,1]`, "{a,NULL,1}", true, nil, true},
+		{
+			"json_arrays_nested",
+			&models.Field{Name: "m", FieldType: "array"},
+			arrayKind,
+			`[[1,2],[3]]`,
+			"{{1,2},{4}}",
+			true,
+			[]Difference{{Name: "m [2][1]", Value1: "3", Value2: "4"}},
+			true,
+		},
+		{"json_arrays_disabled", &models.Field{Name: "tags", FieldType: "array"}, arrayKind, `["a"]`, "{a}", false, nil, false},
+		{
+			"composite",
+			&models.Field{Name: "address", FieldType: "user-defined"},
+			compositeKind,
+			`(Paris,"1 Main St",)`,
+			`("Paris","2 Main St",75001)`,
+			false,
+			[]Difference{
+				{Name: "address [2]", Value1: "1 Main St", Value2: "2 Main St"},
+				{Name: "address [3]", Value1: "NULL", Value2: "75001"},
+			},
+			true,
+		},
+		{"not_composite", &models.Field{Name: "mood", FieldType: "user-defined"}, compositeKind, "happy", "sad", false, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualDifferences, actualOk := arrayDifferences(context.Background(), tt.field, tt.kind, tt.value1, tt.value2, tt.jsonArrays)
+			require.Equal(t, tt.expectedDifferences, actualDifferences)
+			require.Equal(t, tt.expectedOk, actualOk)
+		})
+	}
+}
+
+func TestArrayElementType(t *testing.T) {
+	var tests = []struct {
+		name     string
+		field    *models.Field
+		expected string
+	}{
+		{"declared", &models.Field{FieldType: "int4[]"}, "int4"},
+		{"postgres", &models.Field{FieldType: "array", Attrs: "int4"}, "int4"},
+		{"postgres_primary_key", &models.Field{FieldType: "array", Attrs: "int4 primary key"}, "int4"},
+		{"unknown", &models.Field{FieldType: "array"}, "text"},
+		{"unknown_primary_key", &models.Field{FieldType: "array", Attrs: "primary key"}, "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, arrayElementType(tt.field))
+		})
+	}
+}
+
+func TestSqliteParseArray(t *testing.T) {
+	table := models.Table{Schema: "CREATE TABLE t (id INTEGER PRIMARY KEY, tags TEXT[], n INT4[] NOT NULL)", FieldNameIndex: map[string]int{}}
+	sqlite.Parse(&table)
+	require.Equal(t, "text[]", table.Fields[1].FieldType)
+	require.Equal(t, "int4[]", table.Fields[2].FieldType)
+	require.Equal(t, arrayKind, kindOf(context.Background(), table.Fields[1]))
+}
+
+func TestGetDifferencesArray(t *testing.T) {
+	var (
+		fields      = []*models.Field{{Name: "tags", FieldType: "text[]"}, {Name: "ids", FieldType: "array"}}
+		ctx         = context.WithValue(mockV0Ctx, JSONArraysContextKey, true)
+		values1     = validValues(`{"a b",c}`, `[1,2]`)
+		values2     = validValues(`{"a b",d}`, `{1,2}`)
+		differences []Difference
+		equal       = true
+	)
+
+	getDifferences(ctx, fields, values1, values2, &differences, &equal)
+	require.False(t, equal)
+	require.Equal(t, []Difference{{Name: "tags [2]", Value1: "c", Value2: "d"}}, differences)
+}
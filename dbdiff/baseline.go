@@ -0,0 +1,265 @@
+package dbdiff
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// baselineHeader is a header of a baseline file written on baseline update.
+const baselineHeader = "# Differences accepted by dbdiff, one per line: table [column [key]].\n" +
+	"# Table and column are glob patterns, key is a primary key value. Omitted column and key, or \"*\", match any.\n" +
+	"# A line without a column and key accepts all the table differences, including its absence in a database."
+
+// baselineEntry identifies accepted differences: of a table, of a table column, of a table row with a given
+// primary key value, or of a column of such a row. Empty column and key match any.
+type baselineEntry struct {
+	table, column, key string
+}
+
+// String returns the entry representation in a baseline file.
+func (e baselineEntry) String() string {
+	switch {
+	case len(e.key) > 0 && len(e.column) > 0:
+		return fmt.Sprintf("%s %s %s", e.table, e.column, e.key)
+	case len(e.key) > 0:
+		return fmt.Sprintf("%s * %s", e.table, e.key)
+	case len(e.column) > 0:
+		return fmt.Sprintf("%s %s", e.table, e.column)
+	default:
+		return e.table
+	}
+}
+
+// match reports whether a difference of a given table column and row is accepted by the entry.
+// An empty column denotes a table or a whole row difference, an empty key denotes a table or a schema difference.
+func (e baselineEntry) match(table, column, key string) bool {
+	if !models.GlobMatch(e.table, table) {
+		return false
+	}
+	if len(e.column) > 0 && !models.GlobMatch(e.column, column) {
+		return false
+	}
+	return len(e.key) == 0 || e.key == key
+}
+
+// baseline holds accepted differences, which are not output and do not make databases different.
+type baseline struct {
+	entries []baselineEntry
+}
+
+// match reports whether a difference of a given table column and row is accepted by any of the baseline entries.
+func (b *baseline) match(table, column, key string) bool {
+	if b == nil {
+		return false
+	}
+	for _, e := range b.entries {
+		if e.match(table, column, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBaselineEntry parses a baseline file line. Fields are separated by spaces, key is the rest of the line.
+func parseBaselineEntry(line string) baselineEntry {
+	var e baselineEntry
+	e.table, line = cutField(line)
+	e.column, e.key = cutField(line)
+	if e.column == "*" {
+		e.column = ""
+	}
+	if e.key == "*" {
+		e.key = ""
+	}
+	return e
+}
+
+// cutField returns the first space-separated field of a given string and the rest of it, trimmed.
+func cutField(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// readBaseline reads a baseline file with a given path. Empty lines and lines starting with "#" are skipped.
+// If missing is true, a file which does not exist is read as an empty baseline.
+func readBaseline(path string, missing bool) (*baseline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if missing && errors.Is(err, fs.ErrNotExist) {
+			return &baseline{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	b := &baseline{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		b.entries = append(b.entries, parseBaselineEntry(line))
+	}
+	return b, scanner.Err()
+}
+
+// writeBaseline writes given entries, sorted and deduplicated, to a baseline file with a given path.
+func writeBaseline(path string, entries []baselineEntry) error {
+	lines := make([]string, 0, len(entries))
+	seen := make(map[baselineEntry]struct{}, len(entries))
+	for _, e := range entries {
+		if _, ok := seen[e]; !ok {
+			seen[e] = struct{}{}
+			lines = append(lines, e.String())
+		}
+	}
+	sort.Strings(lines)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err = fmt.Fprintln(file, baselineHeader); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err = fmt.Fprintln(file, line); err != nil {
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// baselineFilter removes a table differences accepted by the baseline, if any, and records all the table
+// differences, if the baseline is updated. Values of differences which are not accepted are recorded, if requested.
+type baselineFilter struct {
+	baseline *baseline
+	table    string
+	record   bool
+	// recordChanges indicates whether differences values are recorded.
+	recordChanges bool
+	// suppressed is a number of removed differences.
+	suppressed int
+	// entries holds recorded differences.
+	entries []baselineEntry
+	// changes holds recorded differences values.
+	changes []change
+}
+
+// newBaselineFilter returns a new baselineFilter object for a given table.
+func newBaselineFilter(ctx context.Context, table string) *baselineFilter {
+	return &baselineFilter{
+		baseline:      fromContext(ctx, acceptedDifferencesContextKey, (*baseline)(nil)),
+		table:         table,
+		record:        fromContext(ctx, UpdateBaselineContextKey, false),
+		recordChanges: fromContext(ctx, recordChangesContextKey, false),
+	}
+}
+
+// absent reports whether the table absence in one of the databases is accepted.
+// A given flag indicates whether the table exists in database1 rather than in database2.
+func (f *baselineFilter) absent(in1 bool) bool {
+	if f.record {
+		f.entries = append(f.entries, baselineEntry{table: f.table})
+	}
+	if f.baseline.match(f.table, "", "") {
+		f.suppressed++
+		return true
+	}
+	if f.recordChanges {
+		f.changes = append(f.changes, change{table: f.table, base: presence(in1), value: presence(!in1)})
+	}
+	return false
+}
+
+// filter removes accepted differences of a table row with a given primary key value from given differences.
+// An empty key denotes schema differences. If whole is true, differences are of a row existing in one of
+// the databases only, or of rows hashes, which are accepted as a whole. Returns the remaining differences
+// and whether all of them are equal.
+func (f *baselineFilter) filter(key string, differences []Difference, whole bool) ([]Difference, bool) {
+	if whole {
+		if f.record {
+			f.entries = append(f.entries, baselineEntry{table: f.table, key: key})
+		}
+		if f.baseline.match(f.table, "", key) {
+			f.suppressed++
+			return equalDifferences(differences), true
+		}
+		for _, d := range differences {
+			f.recordChange(key, d)
+		}
+		return differences, false
+	}
+
+	remaining := make([]Difference, 0, len(differences))
+	equal := true
+	for _, d := range differences {
+		if d.Equal {
+			remaining = append(remaining, d)
+			continue
+		}
+		column := differenceColumn(d.Name)
+		if f.record {
+			f.entries = append(f.entries, baselineEntry{table: f.table, column: column, key: key})
+		}
+		if f.baseline.match(f.table, column, key) {
+			f.suppressed++
+			continue
+		}
+		f.recordChange(key, d)
+		remaining = append(remaining, d)
+		equal = false
+	}
+	return remaining, equal
+}
+
+// recordChange records values of a given difference of a table row with a given primary key value, if requested.
+func (f *baselineFilter) recordChange(key string, d Difference) {
+	if f.recordChanges && !d.Equal {
+		f.changes = append(f.changes, change{
+			table: f.table, name: d.Name, key: key,
+			base: strings.TrimSpace(d.Value1), value: strings.TrimSpace(d.Value2),
+		})
+	}
+}
+
+// apply adds the number of removed differences and the recorded ones to a given result.
+func (f *baselineFilter) apply(r tableResult) tableResult {
+	r.suppressed = f.suppressed
+	r.baseline = f.entries
+	r.changes = f.changes
+	return r
+}
+
+// equalDifferences returns equal values from given differences.
+func equalDifferences(differences []Difference) []Difference {
+	var equal []Difference
+	for _, d := range differences {
+		if d.Equal {
+			equal = append(equal, d)
+		}
+	}
+	return equal
+}
+
+// differenceColumn returns a name of the column of a given difference. Differences inside structured values
+// are named after the column followed by a path, e.g. "data $.a" or "tags[1]".
+func differenceColumn(name string) string {
+	if i := strings.IndexAny(name, " ["); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
@@ -0,0 +1,148 @@
+package dbdiff
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineEntryMatch(t *testing.T) {
+	var tests = []struct {
+		name               string
+		line               string
+		table, column, key string
+		expected           bool
+	}{
+		{"table absence", "users", "users", "", "", true},
+		{"table any difference", "users", "users", "name", "2", true},
+		{"table pattern", "user*", "users", "", "", true},
+		{"other table", "orders", "users", "", "", false},
+		{"column schema difference", "users legacy", "users", "legacy", "", true},
+		{"column data difference", "users legacy", "users", "legacy", "2", true},
+		{"column does not match table absence", "users legacy", "users", "", "", false},
+		{"column does not match whole row", "users legacy", "users", "", "2", false},
+		{"column case-insensitive", "users Legacy", "users", "legacy", "2", true},
+		{"row any column", "users * 2", "users", "name", "2", true},
+		{"whole row", "users * 2", "users", "", "2", true},
+		{"row does not match schema difference", "users * 2", "users", "name", "", false},
+		{"other row", "users * 2", "users", "name", "3", false},
+		{"row column", "users name 2", "users", "name", "2", true},
+		{"row other column", "users name 2", "users", "email", "2", false},
+		{"key with spaces", "users name a  b", "users", "name", "a  b", true},
+		{"malformed pattern", "users[", "users[", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, parseBaselineEntry(tt.line).match(tt.table, tt.column, tt.key))
+		})
+	}
+}
+
+func TestBaselineFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.txt")
+	entries := []baselineEntry{
+		{table: "users", column: "name", key: "2"},
+		{table: "orders"},
+		{table: "users", key: "3"},
+		{table: "users", column: "legacy"},
+		{table: "users", column: "name", key: "2"},
+	}
+	require.NoError(t, writeBaseline(path, entries))
+
+	b, err := readBaseline(path, false)
+	require.NoError(t, err)
+	require.Equal(t, []baselineEntry{
+		{table: "orders"},
+		{table: "users", key: "3"},
+		{table: "users", column: "legacy"},
+		{table: "users", column: "name", key: "2"},
+	}, b.entries)
+
+	_, err = readBaseline(filepath.Join(t.TempDir(), "missing.txt"), false)
+	require.Error(t, err)
+	b, err = readBaseline(filepath.Join(t.TempDir(), "missing.txt"), true)
+	require.NoError(t, err)
+	require.Empty(t, b.entries)
+}
+
+func TestBaselineFilter(t *testing.T) {
+	ctx := context.WithValue(context.Background(), acceptedDifferencesContextKey, &baseline{entries: []baselineEntry{
+		{table: "users", column: "data"},
+		{table: "users", key: "3"},
+	}})
+	ctx = context.WithValue(ctx, UpdateBaselineContextKey, true)
+	f := newBaselineFilter(ctx, "users")
+
+	differences, equal := f.filter("2", []Difference{
+		{Name: "id", Value1: "2", Value2: "2", Equal: true},
+		{Name: "data $.a", Value1: "1", Value2: "2"},
+		{Name: "name", Value1: "a", Value2: "b"},
+	}, false)
+	require.False(t, equal)
+	require.Equal(t, []Difference{
+		{Name: "id", Value1: "2", Value2: "2", Equal: true},
+		{Name: "name", Value1: "a", Value2: "b"},
+	}, differences)
+
+	differences, equal = f.filter("3", []Difference{{Name: "id", Value1: "3"}, {Name: "name", Value1: "c"}}, true)
+	require.True(t, equal)
+	require.Empty(t, differences)
+
+	require.False(t, f.absent(true))
+
+	r := f.apply(newFinalTableResult("users", ""))
+	require.Equal(t, 2, r.suppressed)
+	require.Equal(t, []baselineEntry{
+		{table: "users", column: "data", key: "2"},
+		{table: "users", column: "name", key: "2"},
+		{table: "users", key: "3"},
+		{table: "users"},
+	}, r.baseline)
+}
+
+func TestCompareBaselineSqlite(t *testing.T) {
+	// aliases keep the differences headers independent of the databases files paths
+	input1 := "Database1=" + createSqliteDatabase(t, "d1.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE audit (id INTEGER PRIMARY KEY);
+		INSERT INTO users VALUES (1, 'a'), (2, 'b'), (3, 'c');`,
+	)
+	input2 := "Database2=" + createSqliteDatabase(t, "d2.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, legacy TEXT);
+		INSERT INTO users VALUES (1, 'a', 'x'), (2, 'B', 'y'), (4, 'd', 'z');`,
+	)
+	path := filepath.Join(t.TempDir(), "baseline.txt")
+	dc := &databaseComparer{}
+
+	// all the differences are recorded on baseline update
+	ctx := context.WithValue(context.Background(), BaselineContextKey, path)
+	var out bytes.Buffer
+	printer, _ := dc.compare(context.WithValue(ctx, UpdateBaselineContextKey, true), input1, input2, &out)
+	require.True(t, printer.differences)
+	require.NoError(t, writeBaseline(path, printer.baseline))
+	b, err := readBaseline(path, false)
+	require.NoError(t, err)
+	require.Equal(t, []baselineEntry{{table: "audit"}, {table: "users", column: "legacy"}}, b.entries)
+
+	// data is compared once the schema difference is accepted
+	out.Reset()
+	printer, _ = dc.compare(ctx, input1, input2, &out)
+	require.True(t, printer.differences)
+	require.Equal(t, "Table users data differences:\n"+
+		"  line 2 (id=2):\n    Field   Database1   Database2\n    name    b           B\n\n"+
+		"  line 3 (id=3):\n    Field   Database1   Database2\n    id      3           \n    name    c           \n\n"+
+		"  line 4:\n    Field   Database1   Database2\n    id                  4\n    name                d\n\n"+
+		"suppressed: 2\n", out.String())
+
+	printer, _ = dc.compare(context.WithValue(ctx, UpdateBaselineContextKey, true), input1, input2, io.Discard)
+	require.NoError(t, writeBaseline(path, printer.baseline))
+	out.Reset()
+	printer, _ = dc.compare(ctx, input1, input2, &out)
+	require.False(t, printer.differences)
+	require.Equal(t, "suppressed: 5\n", out.String())
+}
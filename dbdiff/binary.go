@@ -0,0 +1,131 @@
+package dbdiff
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+const (
+	// binaryHexBytes is a number of leading bytes of binary values output as hex.
+	binaryHexBytes = 16
+	// binaryDigestLength is a number of leading hex digits of binary values SHA-256 digests in comparison output.
+	binaryDigestLength = 16
+	// binaryHashPrefix prefixes binary values replaced with their hashes.
+	binaryHashPrefix = "sha256:"
+)
+
+// binaryValue is a scan destination of a binary field value. Values returned by the driver as text, e.g. PostgreSQL
+// bytea text copied into SQLite text fields or binary values of snapshots, are decoded, see [decodeBinary].
+// Values returned as bytes, e.g. SQLite blobs or PostgreSQL bytea values, are raw bytes already and are kept as is.
+type binaryValue struct {
+	*sql.NullString
+}
+
+func (v binaryValue) Scan(src any) error {
+	if err := v.NullString.Scan(src); err != nil {
+		return err
+	}
+	if s, ok := src.(string); ok {
+		v.String = decodeBinary(s)
+	}
+	return nil
+}
+
+// scanDestinations returns destinations a retrieved row is scanned into: given raw values, which are
+// *sql.NullString values of given fields, optionally preceded by the primary key value, with binary fields
+// values wrapped, see [binaryValue].
+func scanDestinations(ctx context.Context, fields []*models.Field, raw []any) []any {
+	dest := make([]any, len(raw))
+	copy(dest, raw)
+	offset := len(raw) - len(fields)
+	for i, f := range fields {
+		if ns, ok := raw[offset+i].(*sql.NullString); ok && kindOf(ctx, f) == binaryKind {
+			dest[offset+i] = binaryValue{ns}
+		}
+	}
+	return dest
+}
+
+// decodeBinary returns raw bytes of a binary value returned by a driver as text. Values in PostgreSQL bytea hex
+// (\x0102) or escape (\001\002) text formats are decoded. Other values are returned as is.
+func decodeBinary(value string) string {
+	if strings.HasPrefix(value, `\x`) {
+		if b, err := hex.DecodeString(value[2:]); err == nil {
+			return string(b)
+		}
+		return value
+	}
+	if b, ok := decodeByteaEscape(value); ok {
+		return b
+	}
+	return value
+}
+
+// decodeByteaEscape decodes a value in PostgreSQL bytea escape format. Returns false if the value is not entirely
+// composed of printable ASCII characters and valid escapes, or contains no escapes at all.
+func decodeByteaEscape(value string) (string, bool) {
+	var (
+		b       strings.Builder
+		escaped bool
+	)
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && i+1 < len(value) && value[i+1] == '\\':
+			b.WriteByte('\\')
+			i++
+		case c == '\\' && i+3 < len(value) && isOctal(value[i+1:i+4]):
+			n, _ := strconv.ParseUint(value[i+1:i+4], 8, 8)
+			b.WriteByte(byte(n))
+			i += 3
+		case c < 0x20 || c > 0x7e || c == '\\':
+			return "", false
+		default:
+			b.WriteByte(c)
+			continue
+		}
+		escaped = true
+	}
+	return b.String(), escaped
+}
+
+// isOctal reports whether a string is a three digits octal number not greater than 0377.
+func isOctal(s string) bool {
+	return len(s) == 3 && s[0] >= '0' && s[0] <= '3' &&
+		s[1] >= '0' && s[1] <= '7' && s[2] >= '0' && s[2] <= '7'
+}
+
+// hashBinary replaces a binary value with its length and SHA-256 digest, so that values are compared and output
+// by their digests.
+func hashBinary(value string) string {
+	digest := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("%s%s:%d", binaryHashPrefix, hex.EncodeToString(digest[:]), len(value))
+}
+
+// formatBinary returns a binary value representation used in comparison output: leading bytes as hex,
+// followed by the value length and SHA-256 digest. Values replaced with hashes are output without leading bytes.
+func formatBinary(value string, hashed bool) string {
+	if hashed {
+		if digest, length, ok := strings.Cut(strings.TrimPrefix(value, binaryHashPrefix), ":"); ok {
+			return fmt.Sprintf("(%s bytes, sha256:%s)", length, digest[:binaryDigestLength])
+		}
+		return value
+	}
+
+	digest := sha256.Sum256([]byte(value))
+	prefix, ellipsis := value, ""
+	if len(value) > binaryHexBytes {
+		prefix, ellipsis = value[:binaryHexBytes], "…"
+	}
+	return fmt.Sprintf(
+		"0x%s%s (%d bytes, sha256:%s)",
+		hex.EncodeToString([]byte(prefix)), ellipsis, len(value), hex.EncodeToString(digest[:])[:binaryDigestLength],
+	)
+}
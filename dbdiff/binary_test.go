@@ -0,0 +1,137 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+func TestDecodeBinary(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"raw", "\x00\x01\xff", "\x00\x01\xff"},
+		{"hex", `\x0001ff`, "\x00\x01\xff"},
+		{"invalid_hex", `\x0g`, `\x0g`},
+		{"escape", `\000\001ab\\`, "\x00\x01ab\\"},
+		{"printable", "abc", "abc"},
+		{"invalid_escape", `\9ab`, `\9ab`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, decodeBinary(tt.value))
+		})
+	}
+}
+
+func TestBinaryValueScan(t *testing.T) {
+	var tests = []struct {
+		name     string
+		src      any
+		expected sql.NullString
+	}{
+		{"bytes", []byte("\x00\x01"), sql.NullString{String: "\x00\x01", Valid: true}},
+		{"bytes_looking_like_hex", []byte(`\x0001`), sql.NullString{String: `\x0001`, Valid: true}},
+		{"text_hex", `\x0001`, sql.NullString{String: "\x00\x01", Valid: true}},
+		{"text_escape", `\000\001`, sql.NullString{String: "\x00\x01", Valid: true}},
+		{"null", nil, sql.NullString{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ns sql.NullString
+			require.NoError(t, binaryValue{&ns}.Scan(tt.src))
+			require.Equal(t, tt.expected, ns)
+		})
+	}
+}
+
+func TestScanDestinations(t *testing.T) {
+	fields := []*models.Field{{Name: "data", FieldType: "blob"}, {Name: "name", FieldType: "text"}}
+	raw := []any{new(sql.NullString), new(sql.NullString), new(sql.NullString)}
+	dest := scanDestinations(context.Background(), fields, raw)
+	require.Equal(t, []any{raw[0], binaryValue{raw[1].(*sql.NullString)}, raw[2]}, dest)
+}
+
+func TestFormatBinary(t *testing.T) {
+	require.Equal(t, "0x0001ff (3 bytes, sha256:26a66b061e8f48f3)", formatBinary("\x00\x01\xff", false))
+	require.Equal(
+		t,
+		"0x000102030405060708090a0b0c0d0e0f… (17 bytes, sha256:3e5718fea51a8f3f)",
+		formatBinary("\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10", false),
+	)
+	require.Equal(t, "(3 bytes, sha256:26a66b061e8f48f3)", formatBinary(hashBinary("\x00\x01\xff"), true))
+}
+
+func TestGetDifferencesBinary(t *testing.T) {
+	var (
+		fields  = []*models.Field{{Name: "data", FieldType: "bytea"}}
+		hashCtx = context.WithValue(mockV0Ctx, BinaryHashOnlyContextKey, true)
+	)
+
+	var tests = []struct {
+		name                string
+		ctx                 context.Context
+		value1              any
+		value2              any
+		expectedDifferences []Difference
+	}{
+		{"equal_representations", mockV0Ctx, []byte("\x00\x01\xff"), `\x0001ff`, nil},
+		{"equal_hashes", hashCtx, []byte("\x00\x01\xff"), `\x0001ff`, nil},
+		{
+			"bytes_not_decoded",
+			mockV0Ctx, []byte(`\x0001`), []byte("\x00\x01"),
+			[]Difference{{
+				Name:   "data",
+				Value1: "0x5c7830303031 (6 bytes, sha256:75b62966bebc8223)",
+				Value2: "0x0001 (2 bytes, sha256:b413f47d13ee2fe6)",
+			}},
+		},
+		{
+			"different",
+			mockV0Ctx, "\x00\x01", "\x00\x02",
+			[]Difference{{
+				Name:   "data",
+				Value1: "0x0001 (2 bytes, sha256:b413f47d13ee2fe6)",
+				Value2: "0x0002 (2 bytes, sha256:fcf0a6c700dd13e2)",
+			}},
+		},
+		{
+			"different_hashes",
+			hashCtx, "\x00\x01", "\x00\x02",
+			[]Difference{{
+				Name:   "data",
+				Value1: "(2 bytes, sha256:b413f47d13ee2fe6)",
+				Value2: "(2 bytes, sha256:fcf0a6c700dd13e2)",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				differences []Difference
+				equal       = true
+				table       = models.Table{Fields: fields}
+				values1     = make([]sql.NullString, 1)
+				values2     = make([]sql.NullString, 1)
+				raw1        = []any{new(sql.NullString)}
+				raw2        = []any{new(sql.NullString)}
+			)
+			require.NoError(t, scanDestinations(tt.ctx, fields, raw1)[0].(sql.Scanner).Scan(tt.value1))
+			require.NoError(t, scanDestinations(tt.ctx, fields, raw2)[0].(sql.Scanner).Scan(tt.value2))
+			table.ParseRawSQLValues(&raw1, &values1, newValueConverter(tt.ctx, sqlite))
+			table.ParseRawSQLValues(&raw2, &values2, newValueConverter(tt.ctx, postgresql))
+			getDifferences(tt.ctx, fields, values1, values2, &differences, &equal)
+			require.Equal(t, tt.expectedDifferences, differences)
+			require.Equal(t, len(tt.expectedDifferences) == 0, equal)
+		})
+	}
+}
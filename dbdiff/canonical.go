@@ -0,0 +1,33 @@
+package dbdiff
+
+import (
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// booleanValues maps lowercased representations of boolean values to canonical ones.
+var booleanValues = map[string]string{
+	"true":  "true",
+	"t":     "true",
+	"yes":   "true",
+	"y":     "true",
+	"on":    "true",
+	"1":     "true",
+	"false": "false",
+	"f":     "false",
+	"no":    "false",
+	"n":     "false",
+	"off":   "false",
+	"0":     "false",
+}
+
+// canonicalizeBoolean converts a boolean value into either "true" or "false".
+// Values of unknown representations are lowercased.
+func canonicalizeBoolean(_ *models.Field, value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if canonical, ok := booleanValues[value]; ok {
+		return canonical
+	}
+	return value
+}
@@ -0,0 +1,35 @@
+package dbdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+func TestCanonicalizeBoolean(t *testing.T) {
+	var tests = []struct {
+		dbType   models.DatabaseType
+		field    models.Field
+		value    string
+		expected string
+	}{
+		{sqlite, mockBooleanField, "1", "true"},
+		{sqlite, mockBooleanField, "0", "false"},
+		{sqlite, mockBooleanField, "TRUE", "true"},
+		{sqlite, models.Field{Name: "flag", FieldType: "bool"}, "t", "true"},
+		{sqlite, mockBooleanField, "maybe", "maybe"},
+		{sqlite, mockTextField, "1", "1"},
+		{postgresql, mockBooleanField, "false", "false"},
+		{postgresql, mockBooleanField, " Yes ", "true"},
+		{postgresql, mockBooleanField, "off", "false"},
+		{postgresql, mockTextField, "TRUE", "TRUE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dbType.Name()+"_"+tt.field.FieldType+"_"+tt.value, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.dbType.Canonicalizers().Canonicalize(&tt.field, tt.value))
+		})
+	}
+}
@@ -3,11 +3,15 @@ package dbdiff
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	_ "github.com/lib/pq"  // package is not used directly
 	_ "modernc.org/sqlite" // package is not used directly
@@ -31,110 +35,380 @@ func (dc *databaseComparer) parse(input string, database any) {
 		uri                    string
 	)
 	d, _ := database.(*models.Database)
+	d.Label = displayName(input)
+	_, input = cutAlias(input)
 	if _, uri, isSqlite = strings.Cut(input, fmt.Sprintf("%s:", sqlite.Name())); isSqlite {
 		d.DBType = sqlite
 		d.URI = uri
 		if _, err := osStat(uri); err != nil {
-			log.Fatalf("database file %s does not exist\n", uri)
+			log.Fatalf("database file %s does not exist\n", Redact(uri))
 		}
 	} else if _, uri, isPostgresql = strings.Cut(input, fmt.Sprintf("%s:", postgresql.Name())); isPostgresql {
 		d.DBType = postgresql
 		d.URI = uri
+	} else if strings.HasPrefix(input, snapshotPrefix) {
+		path := strings.TrimPrefix(input, snapshotPrefix)
+		dbType, uri, err := loadSnapshot(context.Background(), path)
+		if err != nil {
+			log.Fatalf("cannot load snapshot %s: %v\n", path, redactError(err))
+		}
+		d.DBType = dbType
+		d.URI = uri
 	} else {
 		log.Fatalln("unknown database type")
 	}
 }
 
-// createHandler creates a handler for given database.
-func (dc *databaseComparer) createHandler(database *models.Database) {
+// createHandler creates a handler for the n-th compared database, 1 or 2, with connections pool limited according
+// to options set in context.
+func (dc *databaseComparer) createHandler(ctx context.Context, database *models.Database, n int) error {
 	var err error
 	database.Handler, err = sql.Open(database.DBType.Driver(), database.URI)
 	if err != nil {
-		log.Fatalln("cannot create database handler:", err)
+		return fmt.Errorf("cannot create database handler: %s", redactError(err))
 	}
+	// each data comparison worker uses at most one connection to a database at a time
+	database.Handler.SetMaxOpenConns(fromContext(ctx, MaxConnectionsContextKey, [2]int{})[n-1])
+	database.Handler.SetMaxIdleConns(fromContext(ctx, ParallelContextKey, DefaultParallel))
+	return nil
 }
 
-// Compare performs two databases schemas and data comparison.
-func (dc *databaseComparer) Compare(ctx context.Context, input1, input2 string) {
+// beginSnapshot starts a transaction given database reads are performed in.
+func (dc *databaseComparer) beginSnapshot(ctx context.Context, database *models.Database, name string) error {
+	if err := database.BeginSnapshot(ctx); err != nil {
+		return fmt.Errorf("cannot start %s read transaction: %s", name, redactError(err))
+	}
+	return nil
+}
+
+// Compare performs two databases schemas and data comparison and reports whether databases differ.
+// Each database reads are performed in a single snapshot of data, so that concurrent writes do not produce differences.
+// Differences accepted by the baseline, if any, are not output and do not make databases different.
+func (dc *databaseComparer) Compare(ctx context.Context, input1, input2 string) bool {
+	printer, target := dc.compare(ctx, input1, input2, os.Stdout)
+
+	if path := fromContext(ctx, EmitSchemaSQLContextKey, ""); len(path) > 0 && ctx.Err() == nil {
+		writeScript(path, "schema", printer.writeSchemaSQL)
+	}
+	if path := fromContext(ctx, EmitDataSQLContextKey, ""); len(path) > 0 && ctx.Err() == nil {
+		writeScript(path, "data", func(w io.Writer) error { return printer.writeDataSQL(w, target) })
+	}
+	if path := fromContext(ctx, BaselineContextKey, ""); fromContext(ctx, UpdateBaselineContextKey, false) &&
+		ctx.Err() == nil {
+		if err := writeBaseline(path, printer.baseline); err != nil {
+			log.Fatalln("cannot write baseline file:", err)
+		}
+	}
+	return printer.differences
+}
+
+// compare performs two databases schemas and data comparison, outputting results to a given writer.
+// Returns the printer holding collected statements, if requested, and database2 type.
+func (dc *databaseComparer) compare(
+	ctx context.Context,
+	input1, input2 string,
+	out io.Writer,
+) (*resultsPrinter, models.DatabaseType) {
 	var d1, d2 models.Database
 
 	// get database types and URIs from database strings
 	dc.parse(input1, &d1)
 	dc.parse(input2, &d2)
 
+	printer, target, err := dc.compareDatabases(ctx, d1, d2, out)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return printer, target
+}
+
+// compareDatabases performs two given databases schemas and data comparison, outputting results to a given writer.
+// Returns the printer holding collected statements, if requested, and database2 type. Invalid options are fatal,
+// while an error reading any of the databases is returned, along with the results collected before the error, if any.
+// If database2 transaction is set, e.g. the synchronization one, database2 is read in it, by a single worker.
+// nolint: funlen
+func (dc *databaseComparer) compareDatabases(
+	ctx context.Context,
+	d1, d2 models.Database,
+	out io.Writer,
+) (*resultsPrinter, models.DatabaseType, error) {
+	// validate options before connecting to databases
+	predicates, err := parseTablePredicates(fromContext(ctx, WhereContextKey, []string(nil)))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	timestampOptions, err := parseTimestampOptions(ctx)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, dbType := range []models.DatabaseType{d1.DBType, d2.DBType} {
+		if s, ok := dbType.(*snapshotDatabase); ok {
+			if err = s.checkTimestampOptions(timestampOptions); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	}
+	parallel := fromContext(ctx, ParallelContextKey, DefaultParallel)
+	if parallel < 1 {
+		log.Fatalln("invalid number of tables compared concurrently:", parallel)
+	}
+	maxConnections := fromContext(ctx, MaxConnectionsContextKey, [2]int{})
+	if maxConnections[0] < 0 || maxConnections[1] < 0 {
+		log.Fatalln("invalid maximum number of open connections:", maxConnections[0], maxConnections[1])
+	}
+	accepted, err := dc.readBaseline(ctx)
+	if err != nil {
+		log.Fatalln("cannot read baseline file:", err)
+	}
+
+	// statements are generated in database2 dialect
+	emits := len(fromContext(ctx, EmitSchemaSQLContextKey, "")) > 0 || len(fromContext(ctx, EmitDataSQLContextKey, "")) > 0
+	if _, ok := d2.DBType.(*snapshotDatabase); ok && (emits || fromContext(ctx, syncContextKey, false)) {
+		log.Fatalln("statements cannot be generated for a snapshot database2")
+	}
+	schemaOnly, dataOnly := fromContext(ctx, SchemaOnlyContextKey, false), fromContext(ctx, DataOnlyContextKey, false)
+	switch {
+	case schemaOnly && dataOnly:
+		log.Fatalln("schema only and data only comparisons are mutually exclusive")
+	case schemaOnly && len(fromContext(ctx, EmitDataSQLContextKey, "")) > 0:
+		log.Fatalln("data statements cannot be generated without data comparison")
+	case dataOnly && len(fromContext(ctx, EmitSchemaSQLContextKey, "")) > 0:
+		log.Fatalln("schema statements cannot be generated without schema comparison")
+	case len(predicates) > 0 && (len(fromContext(ctx, EmitDataSQLContextKey, "")) > 0 || fromContext(ctx, syncContextKey, false)):
+		// rows filtered out in database1 only would be inserted and the ones filtered out in database2 only deleted
+		log.Fatalln("data statements cannot be generated for rows filtered with -where option")
+	}
+
+	// shared snapshots are read by concurrent transactions in addition to the exporting one
+	for i, d := range []*models.Database{&d1, &d2} {
+		if maxConnections[i] == 1 && len(d.DBType.QueryExportSnapshot()) > 0 {
+			log.Fatalf("at least 2 open connections to %s are required for snapshot reads\n", d.Label)
+		}
+	}
+
 	// create database handlers
-	dc.createHandler(&d1)
-	dc.createHandler(&d2)
+	if err = dc.createHandler(ctx, &d1, 1); err != nil {
+		return nil, d2.DBType, err
+	}
+	defer d1.Handler.Close()
+	ownTx2 := d2.Tx == nil
+	if ownTx2 {
+		if err = dc.createHandler(ctx, &d2, 2); err != nil {
+			return nil, d2.DBType, err
+		}
+		defer d2.Handler.Close()
+	} else {
+		// the transaction cannot be used by concurrent workers
+		parallel = 1
+	}
 
-	ctx = context.WithValue(ctx, mixedDBTypesContextKey, d1.DBType.Name() != d2.DBType.Name())
+	statementTimeout := fromContext(ctx, StatementTimeoutContextKey, time.Duration(0))
+	d1.StatementTimeout = statementTimeout
+	if ownTx2 {
+		d2.StatementTimeout = statementTimeout
+	}
+
+	// each database reads are performed in a single snapshot of data
+	if err = dc.beginSnapshot(ctx, &d1, d1.Label); err != nil {
+		return nil, d2.DBType, err
+	}
+	defer d1.EndSnapshot()
+	if ownTx2 {
+		if err = dc.beginSnapshot(ctx, &d2, d2.Label); err != nil {
+			return nil, d2.DBType, err
+		}
+		defer d2.EndSnapshot()
+	}
+
+	ctx = context.WithValue(ctx, mixedDBTypesContextKey, dialectOf(d1.DBType) != dialectOf(d2.DBType))
+	ctx = context.WithValue(ctx, predicatesContextKey, predicates)
+	ctx = context.WithValue(ctx, timestampOptionsContextKey, timestampOptions)
+	ctx = context.WithValue(ctx, acceptedDifferencesContextKey, accepted)
+	ctx = context.WithValue(ctx, labelsContextKey, [2]string{d1.Label, d2.Label})
 
 	// create channels
 	tablesChannel := make(chan *models.Table)
-	resultsChannel := make(chan string)
-	schemasDoneChannel := make(chan bool, 1)
+	resultsChannel := make(chan tableResult)
+	schemasDoneChannel := make(chan error, 1)
+	schemasComparedChannel := make(chan error, 1)
 	dataDoneChannel := make(chan bool, 1)
 
 	wg := &sync.WaitGroup{}
 
 	go compareDatabaseSchemas(ctx, d1, d2, tablesChannel, resultsChannel, schemasDoneChannel)
-	go watchSchemaComparisonCompletion(schemasDoneChannel, tablesChannel)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for t := range tablesChannel {
-			wg.Add(1)
-			go func(t *models.Table) {
-				defer wg.Done()
+	go watchSchemaComparisonCompletion(schemasDoneChannel, tablesChannel, schemasComparedChannel)
+
+	// tables data is compared by a pool of workers, the largest tables first
+	jobsChannel := make(chan *models.Table)
+	go scheduleTables(ctx, tablesChannel, jobsChannel)
+
+	wg.Add(parallel)
+	for i := 0; i < parallel; i++ {
+		go func() {
+			defer wg.Done()
+			for t := range jobsChannel {
 				compareTablesData(ctx, d1, d2, t, resultsChannel)
-			}(t)
-		}
-	}()
+			}
+		}()
+	}
 
 	go watchDataComparisonCompletion(wg, dataDoneChannel, resultsChannel)
 
-	for result := range resultsChannel {
-		fmt.Println(result)
+	// all the compared tables are known on schemas comparison completion
+	printer := newResultsPrinter(out, fromContext(ctx, StreamContextKey, false))
+	var schemasErr error
+	for resultsChannel != nil {
+		select {
+		case result, ok := <-resultsChannel:
+			if !ok {
+				resultsChannel = nil
+				continue
+			}
+			printer.add(result)
+		case schemasErr = <-schemasComparedChannel:
+			schemasComparedChannel = nil
+			printer.seal()
+		}
+	}
+	// results may be all received before schemas comparison completion is
+	if schemasComparedChannel != nil {
+		schemasErr = <-schemasComparedChannel
 	}
+	printer.close()
 
 	<-dataDoneChannel
+
+	if accepted != nil {
+		fmt.Fprintf(out, "suppressed: %d\n", printer.suppressed)
+	}
+
+	return printer, d2.DBType, schemasErr
+}
+
+// readBaseline reads the baseline file set in context, if any. The file may not exist if it is to be updated.
+func (dc *databaseComparer) readBaseline(ctx context.Context) (*baseline, error) {
+	path := fromContext(ctx, BaselineContextKey, "")
+	update := fromContext(ctx, UpdateBaselineContextKey, false)
+	if len(path) == 0 {
+		if update {
+			return nil, errors.New("baseline file path is not set")
+		}
+		return nil, nil
+	}
+	return readBaseline(path, update)
+}
+
+// writeScript writes a script of a given kind to a file with a given path.
+func writeScript(path, kind string, write func(w io.Writer) error) {
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("cannot create %s sql file: %v\n", kind, err)
+	}
+	defer file.Close()
+
+	if err = write(file); err != nil {
+		log.Fatalf("cannot write %s sql file: %v\n", kind, err)
+	}
 }
 
-// watchSchemaComparisonCompletion closes tables channel on schemas comparison completion.
-func watchSchemaComparisonCompletion(done chan bool, tables chan *models.Table) {
-	<-done
+// watchSchemaComparisonCompletion closes tables channel and notifies results output on schemas comparison completion,
+// passing the schemas comparison error, if any.
+func watchSchemaComparisonCompletion(done chan error, tables chan *models.Table, schemasCompared chan error) {
+	err := <-done
 	close(tables)
+	schemasCompared <- err
+}
+
+// scheduleTables collects tables with equal schemas until schemas comparison completion
+// and passes them for data comparison sorted by size in descending order.
+// Sizes are retrieved once schemas queries are completed, so that connections limits cannot be exceeded.
+// Tables are no longer passed after context cancellation.
+func scheduleTables(ctx context.Context, tables, jobs chan *models.Table) {
+	var collected []*models.Table
+	for t := range tables {
+		collected = append(collected, t)
+	}
+	for _, t := range collected {
+		// size is only used for scheduling, tables of unknown size are compared last
+		if err := t.GetSize(ctx); err != nil {
+			t.Size = 0
+		}
+	}
+	sortTablesBySize(collected)
+	for _, t := range collected {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- t
+	}
+	close(jobs)
+}
+
+// sortTablesBySize sorts tables by size in descending order. Tables of equal size are sorted by name.
+func sortTablesBySize(tables []*models.Table) {
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].Size != tables[j].Size {
+			return tables[i].Size > tables[j].Size
+		}
+		return tables[i].Name < tables[j].Name
+	})
 }
 
 // watchDataComparisonCompletion closes results channel on data comparison completion.
-func watchDataComparisonCompletion(wg *sync.WaitGroup, dataComparisonDone chan bool, results chan string) {
+func watchDataComparisonCompletion(wg *sync.WaitGroup, dataComparisonDone chan bool, results chan tableResult) {
 	wg.Wait()
 	close(results)
 	dataComparisonDone <- true
 }
 
-// compareDatabaseSchemas compares two given databases schemas.
+// compareDatabaseSchemas compares two given databases schemas. An error getting any of the databases schema
+// is sent to done channel, unless the context is canceled.
 // nolint: funlen
 func compareDatabaseSchemas(
 	ctx context.Context,
 	d1, d2 models.Database,
 	tables chan *models.Table,
-	results chan string,
-	done chan bool,
+	results chan tableResult,
+	done chan error,
 ) {
 	var (
 		comparedTables []string // keeps track of already compared database tables
 		rows1, rows2   *sql.Rows
 		err            error
+		migrated       []schemaPair // tables pairs schemas migration statements are generated for
+		dropped        []string     // names of tables which exist in database2 only
 	)
+	schemaSQL := len(fromContext(ctx, EmitSchemaSQLContextKey, "")) > 0 || fromContext(ctx, syncContextKey, false)
+	schemaOnly := fromContext(ctx, SchemaOnlyContextKey, false)
+	dataOnly := fromContext(ctx, DataOnlyContextKey, false)
+	commonColumns := fromContext(ctx, commonColumnsContextKey, false)
 	mixedDBTypes := fromContext(ctx, mixedDBTypesContextKey, true)
+	columnFilter := models.NewColumnFilter(fromContext(ctx, IgnoreColumnsContextKey, []string(nil)))
+	tableFilter := models.NewTableFilter(
+		fromContext(ctx, TablesContextKey, []string(nil)),
+		fromContext(ctx, ExcludeTablesContextKey, []string(nil)),
+	)
+	anyVerbose := fromContext(ctx, VerboseContextKey, false)
 	verbose := fromContext(ctx, VVerboseContextKey, false)
 	verbose = fromContext(ctx, VVVerboseContextKey, verbose) || verbose
+	anyVerbose = anyVerbose || verbose
 
-	rows1, err = d1.Handler.Query(d1.DBType.QueryAll())
+	// fail sends an error getting the n-th database schema to done channel
+	fail := func(n int, err error) {
+		if ctx.Err() != nil {
+			done <- nil
+			return
+		}
+		done <- fmt.Errorf("cannot get %s schema: %s", contextLabel(ctx, n, false), redactError(err))
+	}
+
+	query, args := d1.DBType.QueryAll(tableFilter)
+	rows1, cancel1, err := d1.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Panicln("cannot get database1 schema")
+		fail(1, err)
+		return
 	}
+	defer cancel1()
 	defer rows1.Close()
 	for rows1.Next() {
 		var (
@@ -148,25 +422,45 @@ func compareDatabaseSchemas(
 		t2.FieldNameIndex = make(map[string]int)
 
 		if err = t1.GetFieldsFromRow(rows1); err != nil {
-			results <- fmt.Sprintf("%s: error retrieving schema from database1: %q", t1.ID(), err)
+			results <- newFinalTableResult(t1.Name, fmt.Sprintf("%s: error retrieving schema from %s: %q", t1.ID(), contextLabel(ctx, 1, false), redactError(err)))
 			continue
 		}
 
-		comparedTables = append(comparedTables, fmt.Sprintf("'%s'", t1.Name))
+		// filters are not always fully applied by database queries
+		if !tableFilter.Match(t1.Name) {
+			continue
+		}
+
+		comparedTables = append(comparedTables, t1.Name)
 
 		// get table schema from the second database
-		if err = t2.GetFields(t1.Name); err != nil {
+		if err = t2.GetFields(ctx, t1.Name); err != nil {
 			if err == sql.ErrNoRows {
-				results <- t1.ID() + ": does not exist in database2"
+				results <- absentTableResult(ctx, t1.Name, t1.ID()+": does not exist in "+contextLabel(ctx, 2, false), true)
+				if schemaSQL {
+					migrated = append(migrated, schemaPair{t1: &t1})
+				}
 			} else {
-				results <- t1.ID() + fmt.Sprintf(": error retrieving schema from database2: %q", err)
+				results <- newFinalTableResult(t1.Name, t1.ID()+fmt.Sprintf(": error retrieving schema from %s: %q", contextLabel(ctx, 2, false), redactError(err)))
 			}
 			continue
 		}
 
+		// exclude ignored columns from comparison
+		t1.IgnoreFields(columnFilter)
+		t2.IgnoreFields(columnFilter)
+		if schemaSQL {
+			migrated = append(migrated, schemaPair{t1: &t1, t2: &t2})
+		}
+
 		// compare schemas
 		visited := make(map[int]struct{}) // indices of t2 fields which exist in t1
-		t1.ComparisonResult = fmt.Sprintf("%s:\n  schema differences:", t1.ID())
+		t1.ComparisonResult = fmt.Sprintf("%s:", t1.ID())
+		if ignored := ignoredFieldsNames(&t1, &t2); anyVerbose && len(ignored) > 0 {
+			t1.ComparisonResult += fmt.Sprintf("\n  ignored columns: %s", strings.Join(ignored, ", "))
+		}
+		header := t1.ComparisonResult
+		t1.ComparisonResult += "\n  schema differences:"
 		for _, f := range t1.Fields {
 			var t2Type, t2Attr string
 			j, exists := t2.FieldNameIndex[f.Name] // check if a field with the given name exists in t2
@@ -175,21 +469,24 @@ func compareDatabaseSchemas(
 				t2Type = t2.Fields[j].FieldType
 				t2Attr = t2.Fields[j].Attrs
 			}
-			if !exists || f.FieldType != t2Type || (!mixedDBTypes && f.Attrs != t2Attr) {
+			fieldEqual := exists && f.FieldType == t2Type && (mixedDBTypes || f.Attrs == t2Attr)
+			if !fieldEqual {
 				equal = false
 			}
-			if !exists || f.FieldType != t2Type || (!mixedDBTypes && f.Attrs != t2Attr) || verbose {
+			if !fieldEqual || verbose {
 				if mixedDBTypes {
 					differences = append(differences, Difference{
 						Name:   f.Name,
 						Value1: f.FieldType,
 						Value2: t2Type,
+						Equal:  fieldEqual,
 					})
 				} else {
 					differences = append(differences, Difference{
 						Name:   f.Name,
 						Value1: fmt.Sprintf("%s %s", f.FieldType, f.Attrs),
 						Value2: fmt.Sprintf("%s %s", t2Type, t2Attr),
+						Equal:  fieldEqual,
 					})
 				}
 			}
@@ -213,44 +510,197 @@ func compareDatabaseSchemas(
 			}
 		}
 
-		formatDifferences(ctx, &differences, &(t1.ComparisonResult))
+		// columns which exist in one of the databases only are excluded from data only comparison
+		filter := newBaselineFilter(ctx, t1.Name)
+		compared := &t1
+		if dataOnly {
+			compared = new(models.Table)
+			*compared = t1
+			compared.IgnoreFieldsNamed(missingColumns(differences))
+			compared.ComparisonResult = header
+			results <- filter.apply(newTableResult(t1.Name, ""))
+			tables <- compared
+			continue
+		}
+
+		// columns which differences are accepted by the baseline are excluded from data comparison
+		if !equal {
+			accepted := differencesColumns(differences)
+			if differences, equal = filter.filter("", differences, false); equal {
+				compared = new(models.Table)
+				*compared = t1
+				compared.IgnoreFieldsNamed(accepted)
+			}
+		}
+
+		formatDifferences(ctx, &differences, &(compared.ComparisonResult))
+		if !equal && commonColumns && !schemaOnly {
+			// data is compared on the columns existing in both databases, as in data only comparison
+			results <- filter.apply(newTableResult(t1.Name, compared.ComparisonResult))
+			compared = new(models.Table)
+			*compared = t1
+			compared.IgnoreFieldsNamed(missingColumns(differences))
+			compared.ComparisonResult = header
+			tables <- compared
+			continue
+		}
 		if !equal {
-			results <- t1.ComparisonResult
+			results <- filter.apply(newFinalTableResult(t1.Name, compared.ComparisonResult))
 			continue
 		}
 
-		// schemas are equal, continue with data comparison
+		// schemas are equal, continue with data comparison, which results include schemas comparison ones
 		if !verbose {
-			t1.ComparisonResult += " none"
+			compared.ComparisonResult += " none"
+		}
+		if schemaOnly {
+			r := filter.apply(newFinalTableResult(t1.Name, ""))
+			if anyVerbose {
+				r.text = compared.ComparisonResult
+				r.equal = true
+			}
+			results <- r
+			continue
 		}
-		tables <- &t1
+		results <- filter.apply(newTableResult(t1.Name, ""))
+		tables <- compared
+	}
+	if err = rows1.Err(); err != nil {
+		fail(1, err)
+		return
 	}
 
 	// process tables from the second database which are not in comparedTables slice
-	rows2, err = d2.Handler.Query(d2.DBType.QueryExcluded(comparedTables))
+	query, args = d2.DBType.QueryExcluded(comparedTables, tableFilter)
+	rows2, cancel2, err := d2.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Panicln("cannot get database2 schema")
+		fail(2, err)
+		return
 	}
+	defer cancel2()
 	defer rows2.Close()
 	for rows2.Next() {
 		var t models.Table
 		if err = rows2.Scan(&t.Name); err != nil {
-			log.Panicln("cannot get database2 schema")
+			fail(2, err)
+			return
+		}
+		if !tableFilter.Match(t.Name) {
+			continue
+		}
+		results <- absentTableResult(ctx, t.Name, t.ID()+": does not exist in "+contextLabel(ctx, 1, false), false)
+		dropped = append(dropped, t.Name)
+	}
+	if err = rows2.Err(); err != nil {
+		fail(2, err)
+		return
+	}
+
+	if schemaSQL && ctx.Err() == nil {
+		emitSchemaStatements(ctx, d1, d2, migrated, dropped, results)
+	}
+
+	done <- nil
+}
+
+// absentTableResult returns a final result of a table which exists in database1 only, if in1 is true,
+// or in database2 only otherwise, with a given text, unless the table absence is accepted by the baseline.
+func absentTableResult(ctx context.Context, table, text string, in1 bool) tableResult {
+	filter := newBaselineFilter(ctx, table)
+	if filter.absent(in1) {
+		text = ""
+	}
+	return filter.apply(newFinalTableResult(table, text))
+}
+
+// differencesColumns returns names of the columns of given differences which values are not equal.
+func differencesColumns(differences []Difference) []string {
+	var columns []string
+	for _, d := range differences {
+		if !d.Equal {
+			columns = append(columns, differenceColumn(d.Name))
 		}
-		results <- t.ID() + ": does not exist in database1"
 	}
+	return columns
+}
 
-	done <- true
+// missingColumns returns names of the columns of given schema differences which exist in one of the databases only.
+func missingColumns(differences []Difference) []string {
+	var columns []string
+	for _, d := range differences {
+		if !d.Equal && (len(strings.TrimSpace(d.Value1)) == 0 || len(strings.TrimSpace(d.Value2)) == 0) {
+			columns = append(columns, d.Name)
+		}
+	}
+	return columns
+}
+
+// schemaPair holds a database1 table and the database2 one with the same name, nil if it does not exist.
+type schemaPair struct {
+	t1, t2 *models.Table
+}
+
+// emitSchemaStatements sends statements turning database2 tables schemas into database1 ones to results channel.
+// Tables indexes are retrieved once tables schemas queries are completed.
+func emitSchemaStatements(
+	ctx context.Context,
+	d1, d2 models.Database,
+	migrated []schemaPair,
+	dropped []string,
+	results chan tableResult,
+) {
+	destructive := fromContext(ctx, SchemaSQLDestructiveContextKey, false)
+	for _, p := range migrated {
+		script := newSchemaScript(d1.DBType, d2.DBType, destructive)
+		if err := p.t1.GetIndexes(ctx); err != nil {
+			script.note("cannot get table %s indexes from %s: %v", p.t1.Name, d1.Label, redactError(err))
+		}
+		if p.t2 == nil {
+			script.createTable(p.t1)
+		} else {
+			if err := p.t2.GetIndexes(ctx); err != nil {
+				script.note("cannot get table %s indexes from %s: %v", p.t2.Name, d2.Label, redactError(err))
+			}
+			script.alterTable(p.t1, p.t2)
+		}
+		if len(script.statements) > 0 {
+			results <- tableResult{table: p.t1.Name, statements: script.statements}
+		}
+	}
+	for _, name := range dropped {
+		script := newSchemaScript(d1.DBType, d2.DBType, destructive)
+		script.dropTable(name)
+		results <- tableResult{table: name, statements: script.statements}
+	}
+}
+
+// ignoredFieldsNames returns sorted unique names of fields ignored in any of the two given tables.
+func ignoredFieldsNames(t1, t2 *models.Table) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, f := range append(append([]*models.Field{}, t1.IgnoredFields...), t2.IgnoredFields...) {
+		if _, ok := seen[f.Name]; !ok {
+			seen[f.Name] = struct{}{}
+			names = append(names, f.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 // compareTablesData compares given table data in two databases.
 // nolint: funlen, gocyclo
-func compareTablesData(ctx context.Context, d1, d2 models.Database, t *models.Table, results chan string) {
+func compareTablesData(ctx context.Context, d1, d2 models.Database, t *models.Table, results chan tableResult) {
+	if hasRowHashes(d1.DBType) || hasRowHashes(d2.DBType) {
+		compareTablesRowHashes(ctx, d1, d2, t, results)
+		return
+	}
+
 	var (
-		rows1, rows2 *sql.Rows
-		columns      []string // contains pk at 0 position
-		comparedPks  []string // keeps track of already compared primary key values
-		err          error
+		rows1       *sql.Rows
+		comparedPks = make(map[string]struct{}) // identities of primary key values of compared database2 rows
+		end1, end2  func()                      // end tables snapshot reads
+		err         error
 	)
 
 	fieldsNum := len(t.Fields)
@@ -261,21 +711,54 @@ func compareTablesData(ctx context.Context, d1, d2 models.Database, t *models.Ta
 	vverbose := fromContext(ctx, VVerboseContextKey, false)
 	vvverbose := fromContext(ctx, VVVerboseContextKey, false)
 
-	if verbose || vverbose || vvverbose {
-		result = fmt.Sprintf("%s\n  data differences:", t.ComparisonResult)
+	// concurrently compared tables are read in separate transactions sharing databases snapshots, if supported
+	if d1, end1, err = d1.JoinSnapshot(ctx); err != nil {
+		results <- newFinalTableResult(t.Name, fmt.Sprintf("%s: cannot join %s snapshot: %q", t.ID(), contextLabel(ctx, 1, false), redactError(err)))
+		return
 	}
-
-	if rows1, err = d1.Handler.Query(t.QueryDataAll()); err != nil && err != sql.ErrNoRows {
-		results <- result + " cannot get data from database1"
+	defer end1()
+	if d2, end2, err = d2.JoinSnapshot(ctx); err != nil {
+		results <- newFinalTableResult(t.Name, fmt.Sprintf("%s: cannot join %s snapshot: %q", t.ID(), contextLabel(ctx, 2, false), redactError(err)))
 		return
 	}
-	defer rows1.Close()
+	defer end2()
 
-	columns, err = rows1.Columns()
-	if err != nil {
-		results <- result + " cannot get data from database1"
+	t.Predicate = predicateFor(fromContext(ctx, predicatesContextKey, []tablePredicate(nil)), t.Name)
+	ctx = withFieldKinds(ctx, t)
+	convert1 := newValueConverter(ctx, d1.DBType)
+	convert2 := newValueConverter(ctx, d2.DBType)
+
+	// patch holds statements turning database2 table data into database1 one, if requested
+	var patch *dataPatch
+	if len(fromContext(ctx, EmitDataSQLContextKey, "")) > 0 || fromContext(ctx, syncContextKey, false) {
+		patch = newDataPatch(t, d1.DBType, d2.DBType)
+	}
+	// differences accepted by the baseline are not output, while data patch statements are generated for them
+	filter := newBaselineFilter(ctx, t.Name)
+	// fail sends a final result of the table data comparison failure. The table data patch is not generated.
+	fail := func(text string) {
+		r := filter.apply(newFinalTableResult(t.Name, text))
+		if patch != nil {
+			r.patch = &dataPatch{notes: []string{"-- data comparison failed, statements are not generated"}}
+		}
+		results <- r
+	}
+
+	if verbose || vverbose || vvverbose {
+		result = t.ComparisonResult
+		if len(t.Predicate) > 0 {
+			result += fmt.Sprintf("\n  rows filter: %s", t.Predicate)
+		}
+		result += "\n  data differences:"
+	}
+
+	rows1, cancel1, err := d1.QueryContext(ctx, t.QueryDataAll(d1.DBType))
+	if err != nil && err != sql.ErrNoRows {
+		fail(fmt.Sprintf("%s cannot get data from %s: %q", result, contextLabel(ctx, 1, false), redactError(err)))
 		return
 	}
+	defer cancel1()
+	defer rows1.Close()
 
 	line := 0
 
@@ -286,8 +769,8 @@ func compareTablesData(ctx context.Context, d1, d2 models.Database, t *models.Ta
 		equalLine := true
 		rawValues1 := make([]any, fieldsNum+1) // contains primary key value at 0 position
 		rawValues2 := make([]any, fieldsNum)
-		values1 := make([]string, fieldsNum)
-		values2 := make([]string, fieldsNum)
+		values1 := make([]sql.NullString, fieldsNum)
+		values2 := make([]sql.NullString, fieldsNum)
 
 		for i := 0; i < fieldsNum+1; i++ {
 			rawValues1[i] = new(sql.NullString)
@@ -297,86 +780,155 @@ func compareTablesData(ctx context.Context, d1, d2 models.Database, t *models.Ta
 		}
 		var pkValue string // primary key value
 
-		if err := rows1.Scan(rawValues1...); err != nil {
-			result += fmt.Sprintf("\n    line %d: error fetching data from database1", line)
+		if err := rows1.Scan(scanDestinations(ctx, t.Fields, rawValues1)...); err != nil {
+			result += fmt.Sprintf("\n    line %d: error fetching data from %s", line, contextLabel(ctx, 1, false))
 			equal = false
 			continue
 		}
-		for i, el := range rawValues1 {
-			if ns, ok := el.(*sql.NullString); ok && ns.Valid {
-				if i == 0 {
-					pkValue = ns.String
-					comparedPks = append(comparedPks, fmt.Sprintf("'%s'", pkValue))
-				} else {
-					values1[i-1] = ns.String
-					if t.Fields[i-1].FieldType == "boolean" {
-						values1[i-1] = strings.ToLower(values1[i-1])
-					}
-				}
-			}
+		ns, _ := rawValues1[0].(*sql.NullString)
+		if ns != nil && ns.Valid {
+			pkValue = ns.String
+		}
+		fieldsRawValues1 := rawValues1[1:]
+		t.ParseRawSQLValues(&fieldsRawValues1, &values1, convert1)
+		var raw1 []sql.NullString // database1 row values as retrieved, used in data patch
+		if patch != nil {
+			raw1 = make([]sql.NullString, fieldsNum)
+			t.ParseRawSQLValues(&fieldsRawValues1, &raw1, nil)
 		}
 
-		// fetch data for a given pk value from database2
-		if err = d2.FetchDataRowFromTable(t, pkValue, rawValues2); err != nil {
-			result += fmt.Sprintf("\n  line %d (%s=%s):", line, t.PrimaryKey.Name, pkValue)
-			if err != sql.ErrNoRows {
+		// fetch data for a given pk value from database2. The row, if found, is not compared again as a remaining one.
+		err = d2.FetchDataRowFromTable(ctx, t, pkValue, scanDestinations(ctx, t.Fields, rawValues2))
+		if ns != nil && ns.Valid && err != sql.ErrNoRows {
+			comparedPks[primaryKeyIdentity(ctx, t.PrimaryKey, convert1, pkValue)] = struct{}{}
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				values2 = nil // row does not exist in database2
+				if patch != nil {
+					patch.insert(ctx, raw1, values1)
+				}
+			} else {
 				for i := 0; i < fieldsNum; i++ {
-					values2[i] = "error retrieving data"
+					values2[i] = sql.NullString{String: "error retrieving data", Valid: true}
 				}
 			}
-			getDifferences(ctx, fieldsNum, values1, values2, columns, &differences, &equal)
-			formatDifferences(ctx, &differences, &result)
+			getDifferences(ctx, t.Fields, values1, values2, &differences, &equalLine)
+			if values2 == nil {
+				differences, equalLine = filter.filter(pkValue, differences, true)
+			}
+			if !equalLine {
+				equal = false
+				result += fmt.Sprintf("\n  line %d (%s=%s):", line, t.PrimaryKey.Name, pkValue)
+				formatDifferences(ctx, &differences, &result)
+			}
 			continue
 		}
 
-		t.ParseRawSQLValues(&rawValues2, &values2)
-		getDifferences(ctx, fieldsNum, values1, values2, columns, &differences, &equalLine)
+		t.ParseRawSQLValues(&rawValues2, &values2, convert2)
+		getDifferences(ctx, t.Fields, values1, values2, &differences, &equalLine)
+		if !equalLine && patch != nil {
+			patch.update(ctx, raw1, values1, values2)
+		}
+		if !equalLine {
+			differences, equalLine = filter.filter(pkValue, differences, false)
+		}
 		if !equalLine || vvverbose {
 			result += fmt.Sprintf("\n  line %d (%s=%s):", line, t.PrimaryKey.Name, pkValue)
 		}
 		formatDifferences(ctx, &differences, &result)
-
 		if !equalLine {
 			equal = false
 		}
 	}
 
-	// Add remaining data from database2
-	if rows2, err = d2.Handler.Query(t.QueryDataExcluded(&comparedPks)); err != nil && err != sql.ErrNoRows {
-		results <- fmt.Sprintf("%s cannot get data from database2: %q", result, err)
+	if err = rows1.Err(); err != nil {
+		fail(fmt.Sprintf("%s\n  error fetching data from %s: %q", result, contextLabel(ctx, 1, false), redactError(err)))
+		return
+	}
+
+	// Add remaining data from database2 with a single query. Already compared rows are skipped while reading rather
+	// than excluded by the query, so that the query size does not depend on the number of rows. Rows are matched
+	// with the compared ones by primary keys identities, as their representations may differ between databases.
+	rows2, cancel2, err := d2.QueryContext(ctx, t.QueryDataAll(d2.DBType))
+	if err != nil && err != sql.ErrNoRows {
+		fail(fmt.Sprintf("%s cannot get data from %s: %q", result, contextLabel(ctx, 2, false), redactError(err)))
 		return
 	}
+	defer cancel2()
 	defer rows2.Close()
 
 	for rows2.Next() {
-		line++
-
-		var differences []Difference
-		rawValues2 := make([]any, fieldsNum)
-		values1 := make([]string, fieldsNum)
-		values2 := make([]string, fieldsNum)
-		for i := 0; i < fieldsNum; i++ {
+		var (
+			differences []Difference
+			values1     []sql.NullString // row does not exist in database1
+		)
+		rawValues2 := make([]any, fieldsNum+1) // contains primary key value at 0 position
+		values2 := make([]sql.NullString, fieldsNum)
+		for i := 0; i < fieldsNum+1; i++ {
 			rawValues2[i] = new(sql.NullString)
 		}
 
-		if err = rows2.Scan(rawValues2...); err != nil {
-			result += fmt.Sprintf("\n  line %d: error fetching data from database2", line)
+		if err = rows2.Scan(scanDestinations(ctx, t.Fields, rawValues2)...); err != nil {
+			line++
+			result += fmt.Sprintf("\n  line %d: error fetching data from %s", line, contextLabel(ctx, 2, false))
 			equal = false
 			continue
 		}
-		t.ParseRawSQLValues(&rawValues2, &values2)
-		result += fmt.Sprintf("\n  line %d:", line)
-		getDifferences(ctx, fieldsNum, values1, values2, columns, &differences, &equal)
-		formatDifferences(ctx, &differences, &result)
+		pk, _ := rawValues2[0].(*sql.NullString)
+		if pk.Valid {
+			if _, compared := comparedPks[primaryKeyIdentity(ctx, t.PrimaryKey, convert2, pk.String)]; compared {
+				continue
+			}
+		}
+		line++
+		if !pk.Valid {
+			result += fmt.Sprintf("\n  line %d: NULL primary key in %s", line, contextLabel(ctx, 2, false))
+			equal = false
+			continue
+		}
+
+		equalLine := true
+		fieldsRawValues2 := rawValues2[1:]
+		t.ParseRawSQLValues(&fieldsRawValues2, &values2, convert2)
+		getDifferences(ctx, t.Fields, values1, values2, &differences, &equalLine)
+		if patch != nil {
+			patch.delete(ctx, *pk)
+		}
+		differences, equalLine = filter.filter(pk.String, differences, true)
+		if !equalLine {
+			equal = false
+			result += fmt.Sprintf("\n  line %d:", line)
+			formatDifferences(ctx, &differences, &result)
+		}
+	}
+	if err = rows2.Err(); err != nil {
+		fail(fmt.Sprintf("%s\n  error fetching data from %s: %q", result, contextLabel(ctx, 2, false), redactError(err)))
+		return
 	}
 
 	if equal && (verbose || vverbose) {
 		result += " none"
 	}
-
+	r := filter.apply(tableResult{table: t.Name, final: true, equal: equal, patch: finishDataPatch(ctx, d2, patch)})
 	if !equal || verbose || vverbose || vvverbose {
-		results <- result
+		r.text = result
+	}
+	results <- r
+}
+
+// finishDataPatch gets names of tables referenced by a given patch table foreign keys in database2, so that
+// the patch statements can be ordered. Returns nil if the patch holds no statements.
+func finishDataPatch(ctx context.Context, d2 models.Database, patch *dataPatch) *dataPatch {
+	if patch == nil || patch.empty() {
+		return nil
+	}
+	t2 := models.Table{Name: patch.table.Name, DB: &d2}
+	if err := t2.GetReferences(ctx); err != nil {
+		patch.note("cannot get foreign keys from %s, statements may be misordered: %v", d2.Label, redactError(err))
 	}
+	patch.references = t2.References
+	return patch
 }
 
 // osStat is used to simplify testing
@@ -1,6 +1,8 @@
 package dbdiff
 
 import (
+	"bytes"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -39,3 +41,119 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestPrimaryKeyIdentity(t *testing.T) {
+	var tests = []struct {
+		name   string
+		field  models.Field
+		dbType models.DatabaseType
+		value  string
+		other  string
+	}{
+		{"integer", models.Field{Name: "id", FieldType: "integer"}, sqlite, "10", "10"},
+		{"decimal_scale", models.Field{Name: "id", FieldType: "numeric"}, postgresql, "10.00", "10"},
+		{"float", models.Field{Name: "id", FieldType: "float8"}, postgresql, "1e1", "10"},
+		{"uuid_case", models.Field{Name: "id", FieldType: "uuid"}, postgresql, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+			"A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11"},
+		{"boolean", models.Field{Name: "id", FieldType: "boolean"}, postgresql, "true", "1"},
+		{"text", models.Field{Name: "id", FieldType: "text"}, sqlite, "abc", "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			require.Equal(
+				t,
+				primaryKeyIdentity(ctx, &tt.field, newValueConverter(ctx, sqlite), tt.other),
+				primaryKeyIdentity(ctx, &tt.field, newValueConverter(ctx, tt.dbType), tt.value),
+			)
+		})
+	}
+
+	field := models.Field{Name: "id", FieldType: "text"}
+	convert := newValueConverter(context.Background(), sqlite)
+	require.NotEqual(t, primaryKeyIdentity(context.Background(), &field, convert, "ABC"),
+		primaryKeyIdentity(context.Background(), &field, convert, "abc"))
+}
+
+func TestSortTablesBySize(t *testing.T) {
+	tables := []*models.Table{
+		{Name: "b", Size: 10},
+		{Name: "c", Size: 0},
+		{Name: "a", Size: 10},
+		{Name: "d", Size: 1000},
+	}
+
+	sortTablesBySize(tables)
+
+	names := make([]string, 0, len(tables))
+	for _, t := range tables {
+		names = append(names, t.Name)
+	}
+	require.Equal(t, []string{"d", "a", "b", "c"}, names)
+}
+
+func TestCompareScopesSqlite(t *testing.T) {
+	// aliases keep the differences headers independent of the databases files paths
+	input1 := "Database1=" + createSqliteDatabase(t, "d1.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, legacy TEXT);
+		CREATE TABLE tags (id INTEGER PRIMARY KEY, tag TEXT);
+		INSERT INTO users VALUES (1, 'a', 'x'), (2, 'b', 'y');
+		INSERT INTO tags VALUES (1, 'x');`,
+	)
+	input2 := "Database2=" + createSqliteDatabase(t, "d2.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, note TEXT);
+		CREATE TABLE tags (id INTEGER PRIMARY KEY, tag TEXT);
+		INSERT INTO users VALUES (1, 'a', 'n'), (2, 'B', 'n');
+		INSERT INTO tags VALUES (1, 'y');`,
+	)
+
+	var tests = []struct {
+		name     string
+		key      contextKey
+		verbose  bool
+		expected string
+	}{
+		{"schema only", SchemaOnlyContextKey, false, "Table users:\n  schema differences:\n" +
+			"    Field    Database1   Database2\n    legacy   text         \n    note                 text \n\n"},
+		{"schema only verbose", SchemaOnlyContextKey, true, "Table tags:\n  schema differences: none\n" +
+			"Table users:\n  schema differences:\n" +
+			"    Field    Database1   Database2\n    legacy   text         \n    note                 text \n\n"},
+		{"data only", DataOnlyContextKey, false, "Table tags data differences:\n" +
+			"  line 1 (id=1):\n    Field   Database1   Database2\n    tag     x           y\n\n" +
+			"Table users data differences:\n" +
+			"  line 2 (id=2):\n    Field   Database1   Database2\n    name    b           B\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), tt.key, true)
+			ctx = context.WithValue(ctx, VerboseContextKey, tt.verbose)
+			var out bytes.Buffer
+			printer, _ := (&databaseComparer{}).compare(ctx, input1, input2, &out)
+			require.True(t, printer.differences)
+			require.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestCompareRemainingRowsSqlite(t *testing.T) {
+	// rows found by database1 primary keys are not compared again, the ones not found are output as remaining
+	// aliases keep the differences headers independent of the databases files paths
+	input1 := "Database1=" + createSqliteDatabase(t, "d1.db", `
+		CREATE TABLE t (id REAL PRIMARY KEY, name TEXT);
+		INSERT INTO t VALUES (1.0, 'a'), (2.0, 'b');`,
+	)
+	input2 := "Database2=" + createSqliteDatabase(t, "d2.db", `
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO t VALUES (1, 'a'), (3, 'c');`,
+	)
+
+	ctx := context.WithValue(context.Background(), DataOnlyContextKey, true)
+	var out bytes.Buffer
+	printer, _ := (&databaseComparer{}).compare(ctx, input1, input2, &out)
+	require.True(t, printer.differences)
+	require.Equal(t, "Table t data differences:\n"+
+		"  line 2 (id=2):\n    Field   Database1   Database2\n    id      2           \n    name    b           \n\n"+
+		"  line 3:\n    Field   Database1   Database2\n    id                  3\n    name                c\n\n", out.String())
+}
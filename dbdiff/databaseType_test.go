@@ -12,9 +12,9 @@ import (
 
 var (
 	mockIDField         = models.Field{Name: "mock_id_field", FieldType: "text", PrimaryKey: true, Attrs: "primary key"}
-	mockTextField       = models.Field{Name: "mock_text_field", FieldType: "text", PrimaryKey: false, Attrs: "not null"}
-	mockBooleanField    = models.Field{Name: "mock_boolean_field", FieldType: "boolean", PrimaryKey: false, Attrs: "not null"}
-	mockTimestampField  = models.Field{Name: "mock_timestamp_field", FieldType: "timestamp", PrimaryKey: false, Attrs: "not null default current_timestamp"}
+	mockTextField       = models.Field{Name: "mock_text_field", FieldType: "text", PrimaryKey: false, Attrs: "not null", NotNull: true}
+	mockBooleanField    = models.Field{Name: "mock_boolean_field", FieldType: "boolean", PrimaryKey: false, Attrs: "not null", NotNull: true}
+	mockTimestampField  = models.Field{Name: "mock_timestamp_field", FieldType: "timestamp", PrimaryKey: false, Attrs: "not null default current_timestamp", NotNull: true}
 	mockFields          = []*models.Field{&mockIDField, &mockTextField, &mockBooleanField, &mockTimestampField}
 	mockSqliteTable     = newMockTable("mock_sqlite_table", sqlite)
 	mockPostgresqlTable = newMockTable("mock_postgresql_table", postgresql)
@@ -33,16 +33,26 @@ func newMockTable(name string, dbType models.DatabaseType) models.Table {
 		schema += "FOREIGN KEY(mock_parent_ref) REFERENCES mock_parent_table(mock_id_field) ON DELETE CASCADE"
 	}
 
-	fieldNameIndex := make(map[string]int, len(mockFields))
-	for i, f := range mockFields {
+	fields := mockFields
+	if dbType == postgresql {
+		// PostgreSQL schemas NOT NULL suffixes are not kept in fields attributes
+		fields = make([]*models.Field, 0, len(mockFields))
+		for _, f := range mockFields {
+			field := *f
+			field.Attrs = strings.TrimSpace(strings.TrimSuffix(field.Attrs, "not null"))
+			fields = append(fields, &field)
+		}
+	}
+	fieldNameIndex := make(map[string]int, len(fields))
+	for i, f := range fields {
 		fieldNameIndex[f.Name] = i
 	}
 
 	return models.Table{
 		Name:           name,
 		Schema:         schema,
-		PrimaryKey:     &mockIDField,
-		Fields:         mockFields,
+		PrimaryKey:     fields[0],
+		Fields:         fields,
 		FieldNameIndex: fieldNameIndex,
 	}
 }
@@ -71,3 +81,26 @@ func TestDatabaseTypeParse(t *testing.T) {
 		})
 	}
 }
+
+func TestDatabaseTypeParseMixedCase(t *testing.T) {
+	var tests = []struct {
+		name         string
+		dbType       models.DatabaseType
+		schema       string
+		expectedName string
+	}{
+		{"postgres", postgresql, "UserName TEXT PRIMARY KEY", "UserName"},
+		{"sqlite", sqlite, `CREATE TABLE t ("UserName" TEXT PRIMARY KEY)`, "username"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := models.Table{Schema: tt.schema, FieldNameIndex: make(map[string]int)}
+			tt.dbType.Parse(&table)
+			require.Len(t, table.Fields, 1)
+			require.Equal(t, tt.expectedName, table.Fields[0].Name)
+			require.Equal(t, "text", table.Fields[0].FieldType)
+			require.True(t, table.Fields[0].PrimaryKey)
+		})
+	}
+}
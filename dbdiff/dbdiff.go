@@ -3,14 +3,18 @@ package dbdiff
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"fmt"
 	"text/tabwriter"
 	"text/template"
+
+	"github.com/ygrebnov/dbdiff/models"
 )
 
-// Comparer is a type capable of comparing two entities.
+// Comparer is a type capable of comparing two entities and reporting whether they differ.
+// Comparison is stopped on context cancellation, with results obtained so far being output.
 type Comparer interface {
-	Compare(ctx context.Context, entity1 string, entity2 string)
+	Compare(ctx context.Context, entity1 string, entity2 string) bool
 	parse(input string, entity any)
 }
 
@@ -19,44 +23,178 @@ type contextKey string
 
 // Difference is a type capable of holding an identified by name entity values in compared entities.
 // For example, a name of a table field with values in database1 and database2.
+// Equal indicates whether the values are considered equal, even if their representations are different.
 type Difference struct {
 	Name, Value1, Value2 string
+	Equal                bool
 }
 
 const (
-	differencesTemplate = "    Field\tDatabase1\tDatabase2\n{{range .}}    " +
+	differencesHeader   = "    Field\t%s\t%s\n"
+	differencesTemplate = "{{range .}}    " +
 		"{{.Name}}\t{{.Value1}}\t{{.Value2}}\n{{end}}"
-	verboseDifferencesTemplate = "    Field\tDatabase1\tDatabase2\n{{range .}}  " +
-		"{{ if eq .Value1 .Value2 }}={{ else }}x{{ end }} {{.Name}}\t{{.Value1}}\t{{.Value2}}\n{{end}}"
+	verboseDifferencesTemplate = "{{range .}}  " +
+		"{{ if .Equal }}={{ else }}x{{ end }} {{.Name}}\t{{.Value1}}\t{{.Value2}}\n{{end}}"
 	VerboseContextKey      = contextKey("verbose")
 	VVerboseContextKey     = contextKey("vverbose")
 	VVVerboseContextKey    = contextKey("vvverbose")
 	mixedDBTypesContextKey = contextKey("mixedDBTypes")
+	predicatesContextKey   = contextKey("predicates")
+	// timestampOptionsContextKey holds parsed timestamp values comparison options.
+	timestampOptionsContextKey = contextKey("timestampOptions")
+	// fieldKindsContextKey holds kinds of values of the currently compared table fields not defined by field types.
+	fieldKindsContextKey = contextKey("fieldKinds")
+	// syncContextKey holds a flag indicating whether statements synchronizing database2 with database1 are collected.
+	syncContextKey = contextKey("sync")
+	// acceptedDifferencesContextKey holds the parsed baseline of accepted differences.
+	acceptedDifferencesContextKey = contextKey("acceptedDifferences")
+	// recordChangesContextKey holds a flag indicating whether differences values are recorded,
+	// e.g. for three-way comparison.
+	recordChangesContextKey = contextKey("recordChanges")
+	// commonColumnsContextKey holds a flag indicating whether data of tables which schemas differ is compared
+	// on the columns existing in both databases, e.g. for three-way comparison.
+	commonColumnsContextKey = contextKey("commonColumns")
+	// labelsContextKey holds labels identifying the compared databases in output, see [models.Database.Label].
+	labelsContextKey = contextKey("labels")
+)
+
+// Comparison options context keys.
+const (
+	// IgnoreColumnsContextKey holds a slice of glob patterns of columns excluded from comparison.
+	IgnoreColumnsContextKey = contextKey("ignoreColumns")
+	// TablesContextKey holds a slice of glob patterns of tables included into comparison.
+	TablesContextKey = contextKey("tables")
+	// ExcludeTablesContextKey holds a slice of glob patterns of tables excluded from comparison.
+	ExcludeTablesContextKey = contextKey("excludeTables")
+	// WhereContextKey holds a slice of rows filters defined as "table=condition",
+	// where table is a table name glob pattern and condition is an SQL condition.
+	WhereContextKey = contextKey("where")
+	// NullAsEmptyContextKey holds a flag indicating whether NULL values are treated as equal to empty strings.
+	NullAsEmptyContextKey = contextKey("nullAsEmpty")
+	// AbsToleranceContextKey holds a maximum absolute difference between numeric values considered equal.
+	AbsToleranceContextKey = contextKey("absTolerance")
+	// RelToleranceContextKey holds a maximum relative difference between numeric values considered equal.
+	RelToleranceContextKey = contextKey("relTolerance")
+	// TimezoneContextKey holds a name of the time zone of timestamp values without explicit offsets. Default is UTC.
+	TimezoneContextKey = contextKey("timezone")
+	// TimestampPrecisionContextKey holds a precision timestamp values are truncated to before comparison:
+	// "s", "ms", "us" or "ns". Default is "ns".
+	TimestampPrecisionContextKey = contextKey("timestampPrecision")
+	// JSONColumnsContextKey holds a slice of glob patterns of columns compared as JSON documents,
+	// in addition to the ones of json and jsonb types.
+	JSONColumnsContextKey = contextKey("jsonColumns")
+	// BinaryHashOnlyContextKey holds a flag indicating whether binary values are compared by their hashes only.
+	BinaryHashOnlyContextKey = contextKey("binaryHashOnly")
+	// JSONArraysContextKey holds a flag indicating whether array values stored as JSON arrays, e.g. ["a","b"],
+	// are compared to PostgreSQL array values, e.g. {a,b}, element-wise.
+	JSONArraysContextKey = contextKey("jsonArrays")
+	// ParallelContextKey holds a number of tables which data is compared concurrently.
+	ParallelContextKey = contextKey("parallel")
+	// MaxConnectionsContextKey holds maximum numbers of open connections to database1 and database2, [2]int.
+	// Zero means no limit other than the one implied by the number of tables compared concurrently.
+	MaxConnectionsContextKey = contextKey("maxConnections")
+	// StatementTimeoutContextKey holds a time.Duration limiting each query execution time. Zero means no limit.
+	StatementTimeoutContextKey = contextKey("statementTimeout")
+	// StreamContextKey holds a flag indicating whether tables comparison results are output as soon as they are
+	// obtained, while keeping the output ordered by table name, rather than on comparison completion.
+	StreamContextKey = contextKey("stream")
+	// EmitSchemaSQLContextKey holds a path of a file statements turning database2 schema into database1 one,
+	// in database2 dialect, are written to.
+	EmitSchemaSQLContextKey = contextKey("emitSchemaSQL")
+	// SchemaSQLDestructiveContextKey holds a flag indicating whether statements which may lose data,
+	// e.g. dropping tables or columns, are emitted uncommented.
+	SchemaSQLDestructiveContextKey = contextKey("schemaSQLDestructive")
+	// EmitDataSQLContextKey holds a path of a file statements turning database2 data into database1 one,
+	// in database2 dialect, are written to.
+	EmitDataSQLContextKey = contextKey("emitDataSQL")
+	// DryRunContextKey holds a flag indicating whether synchronization statements are output instead of being applied.
+	DryRunContextKey = contextKey("dryRun")
+	// AssumeYesContextKey holds a flag indicating whether synchronization statements are applied without confirmation.
+	AssumeYesContextKey = contextKey("assumeYes")
+	// BatchSizeContextKey holds a number of synchronization statements executed at once.
+	BatchSizeContextKey = contextKey("batchSize")
+	// SnapshotDataContextKey holds a flag indicating whether snapshots hold full data rather than rows hashes.
+	SnapshotDataContextKey = contextKey("snapshotData")
+	// SnapshotGzipContextKey holds a flag indicating whether snapshot files are compressed with gzip.
+	SnapshotGzipContextKey = contextKey("snapshotGzip")
+	// BaselineContextKey holds a path of a baseline file listing accepted differences, which are not output
+	// and do not make compared databases different.
+	BaselineContextKey = contextKey("baseline")
+	// UpdateBaselineContextKey holds a flag indicating whether the baseline file is rewritten with differences
+	// found by the comparison.
+	UpdateBaselineContextKey = contextKey("updateBaseline")
+	// ParallelTargetsContextKey holds a number of target databases compared with the reference one concurrently.
+	ParallelTargetsContextKey = contextKey("parallelTargets")
+	// SchemaOnlyContextKey holds a flag indicating whether only tables presence and schemas are compared.
+	SchemaOnlyContextKey = contextKey("schemaOnly")
+	// DataOnlyContextKey holds a flag indicating whether schemas differences are not reported, with data of columns
+	// existing in both databases being compared regardless of them.
+	DataOnlyContextKey = contextKey("dataOnly")
 )
 
+// DefaultParallel is a default number of tables which data is compared concurrently.
+const DefaultParallel = 4
+
+// defaultBatchSize is a default number of synchronization statements executed at once.
+const defaultBatchSize = 100
+
+// nullValue is NULL value representation in comparison output.
+const nullValue = "NULL"
+
 // getDifferences compares values from two databases and puts their differences in a slice.
+// A nil values slice means that the row does not exist in the corresponding database.
 func getDifferences(
 	ctx context.Context,
-	fieldsNum int,
-	values1, values2, columns []string,
+	fields []*models.Field,
+	values1, values2 []sql.NullString,
 	differences *[]Difference,
 	overallEqual *bool,
 ) {
 	// equal values are added to the differences result only at the third level of verbosity.
 	verbose := fromContext(ctx, VVVerboseContextKey, false)
+	nullAsEmpty := fromContext(ctx, NullAsEmptyContextKey, false)
 
-	for i := 0; i < fieldsNum; i++ {
-		equal := values1[i] == values2[i]
+	for i, field := range fields {
+		value1, exists1 := valueAt(values1, i, nullAsEmpty)
+		value2, exists2 := valueAt(values2, i, nullAsEmpty)
+		var (
+			equal   bool
+			details []Difference // differences inside structured values
+		)
+		if exists1 && exists2 {
+			equal, details = compareValues(ctx, field, value1, value2)
+		}
 		// by default, the overall equality is true. It changes only if any individual values are different.
 		if !equal {
 			*overallEqual = false
 		}
+		if len(details) > 0 {
+			*differences = append(*differences, details...)
+			continue
+		}
 		if !equal || verbose {
-			*differences = append(*differences, Difference{Name: columns[i+1], Value1: values1[i], Value2: values2[i]})
+			*differences = append(*differences, Difference{
+				Name:   field.Name,
+				Value1: formatValue(ctx, field, value1, exists1),
+				Value2: formatValue(ctx, field, value2, exists2),
+				Equal:  equal,
+			})
 		}
 	}
 }
 
+// valueAt returns a value at given index and whether it exists. Values do not exist if the values slice is nil.
+// If nullAsEmpty is true, NULL value is returned as an empty string.
+func valueAt(values []sql.NullString, i int, nullAsEmpty bool) (sql.NullString, bool) {
+	if values == nil {
+		return sql.NullString{}, false
+	}
+	if nullAsEmpty && !values[i].Valid {
+		return sql.NullString{Valid: true}, true
+	}
+	return values[i], true
+}
+
 // formatDifferences formats comparison differences as a table and adds them to the resulting output string.
 // Formatting template depends on the verbosity level.
 func formatDifferences(ctx context.Context, differences *[]Difference, result *string) {
@@ -70,6 +208,7 @@ func formatDifferences(ctx context.Context, differences *[]Difference, result *s
 
 		tmpl := template.Must(template.New("").Parse(t))
 		w := tabwriter.NewWriter(&buff, 5, 0, 3, ' ', 0)
+		fmt.Fprintf(w, differencesHeader, contextLabel(ctx, 1, true), contextLabel(ctx, 2, true))
 		if err := tmpl.Execute(w, differences); err != nil {
 			*result += fmt.Sprintf("\n    error formatting differences: %v", differences)
 		}
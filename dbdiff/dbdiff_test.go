@@ -2,10 +2,13 @@ package dbdiff
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
 )
 
 type mockDifferences []Difference
@@ -24,7 +27,7 @@ func (md mockDifferences) asShortOutput() string {
 func (md mockDifferences) asLongOutput() string {
 	output := "\n    Field   Database1   Database2\n"
 	for _, diff := range md {
-		if diff.Value1 == diff.Value2 {
+		if diff.Equal {
 			output += "  ="
 		} else {
 			output += "  x"
@@ -44,79 +47,103 @@ var (
 	mockV3Ctx       = context.WithValue(context.Background(), VVVerboseContextKey, true)
 	mockS1          = "mocked_s1"
 	mockS2          = "mocked_s2"
-	mockValues      = []string{mockS1, mockS2}
-	mockEmptyValues = []string{"", ""}
-	mockS1Empty     = mockDifferences{{"v1", "", mockS2}, {"v2", "", mockS2}}
-	mockS2Empty     = mockDifferences{{"v1", mockS1, mockS2}, {"v2", mockS2, mockS1}}
-	mockDiff        = mockDifferences{{"v1", mockS1, ""}, {"v2", mockS1, ""}}
-	mockColumns     = []string{"pk", "v1", "v2"}
+	mockValues      = validValues(mockS1, mockS2)
+	mockEmptyValues = validValues("", "")
+	mockNullValues  = []sql.NullString{{}, {}}
+	mockV0NullCtx   = context.WithValue(context.Background(), NullAsEmptyContextKey, true)
+	mockS1Empty     = mockDifferences{{"v1", "", mockS2, false}, {"v2", "", mockS2, false}}
+	mockS2Empty     = mockDifferences{{"v1", mockS1, mockS2, false}, {"v2", mockS2, mockS1, false}}
+	mockDiff        = mockDifferences{{"v1", mockS1, "", false}, {"v2", mockS1, "", false}}
+	mockEqual       = mockDifferences{{"v1", "1.0000000", "1.0", true}, {"v2", mockS1, mockS2, false}}
+	mockValueFields = []*models.Field{{Name: "v1", FieldType: "text"}, {Name: "v2", FieldType: "text"}}
 )
 
+// validValues converts given strings into valid optional strings.
+func validValues(values ...string) []sql.NullString {
+	nullStrings := make([]sql.NullString, 0, len(values))
+	for _, v := range values {
+		nullStrings = append(nullStrings, sql.NullString{String: v, Valid: true})
+	}
+	return nullStrings
+}
+
 func TestGetDifferences(t *testing.T) {
 	var tests = []struct {
 		name                string
 		ctx                 context.Context
-		fieldsNum           int
-		values1             []string
-		values2             []string
-		columns             []string
+		fields              []*models.Field
+		values1             []sql.NullString
+		values2             []sql.NullString
 		expectedDifferences []Difference
 		expectedEqual       bool
 	}{
-		{"v0_equal", mockV0Ctx, 2, mockValues, mockValues, mockColumns, []Difference{}, true},
-		{"v1_equal", mockV1Ctx, 2, mockValues, mockValues, mockColumns, []Difference{}, true},
-		{"v2_equal", mockV2Ctx, 2, mockValues, mockValues, mockColumns, []Difference{}, true},
+		{"v0_equal", mockV0Ctx, mockValueFields, mockValues, mockValues, []Difference{}, true},
+		{"v1_equal", mockV1Ctx, mockValueFields, mockValues, mockValues, []Difference{}, true},
+		{"v2_equal", mockV2Ctx, mockValueFields, mockValues, mockValues, []Difference{}, true},
 		{
-			"v3_equal", mockV3Ctx, 2, mockValues, mockValues, mockColumns,
+			"v3_equal", mockV3Ctx, mockValueFields, mockValues, mockValues,
 			[]Difference{
-				{Name: "v1", Value1: mockS1, Value2: mockS1},
-				{Name: "v2", Value1: mockS2, Value2: mockS2},
+				{Name: "v1", Value1: mockS1, Value2: mockS1, Equal: true},
+				{Name: "v2", Value1: mockS2, Value2: mockS2, Equal: true},
 			},
 			true,
 		},
 		{
-			"v0_not_equal", mockV0Ctx, 2,
+			"v0_not_equal", mockV0Ctx, mockValueFields,
 			mockValues,
-			[]string{mockS1, mockS1},
-			mockColumns,
+			validValues(mockS1, mockS1),
 			[]Difference{{Name: "v2", Value1: mockS2, Value2: mockS1}},
 			false,
 		},
 		{
-			"v1_not_equal", mockV1Ctx, 2,
+			"v1_not_equal", mockV1Ctx, mockValueFields,
 			mockValues,
-			[]string{mockS1, mockS1},
-			mockColumns,
+			validValues(mockS1, mockS1),
 			[]Difference{{Name: "v2", Value1: mockS2, Value2: mockS1}},
 			false,
 		},
 		{
-			"v2_not_equal", mockV2Ctx, 2,
+			"v2_not_equal", mockV2Ctx, mockValueFields,
 			mockValues,
-			[]string{mockS1, mockS1},
-			mockColumns,
+			validValues(mockS1, mockS1),
 			[]Difference{{Name: "v2", Value1: mockS2, Value2: mockS1}},
 			false,
 		},
 		{
-			"v3_not_equal", mockV3Ctx, 2,
+			"v3_not_equal", mockV3Ctx, mockValueFields,
 			mockValues,
-			[]string{mockS1, mockS1},
-			mockColumns,
+			validValues(mockS1, mockS1),
 			[]Difference{
-				{Name: "v1", Value1: mockS1, Value2: mockS1},
+				{Name: "v1", Value1: mockS1, Value2: mockS1, Equal: true},
 				{Name: "v2", Value1: mockS2, Value2: mockS1},
 			},
 			false,
 		},
-		{"v0_empty", mockV0Ctx, 2, mockEmptyValues, mockEmptyValues, mockColumns, []Difference{}, true},
-		{"v1_empty", mockV1Ctx, 2, mockEmptyValues, mockEmptyValues, mockColumns, []Difference{}, true},
-		{"v2_empty", mockV2Ctx, 2, mockEmptyValues, mockEmptyValues, mockColumns, []Difference{}, true},
+		{"v0_empty", mockV0Ctx, mockValueFields, mockEmptyValues, mockEmptyValues, []Difference{}, true},
+		{"v1_empty", mockV1Ctx, mockValueFields, mockEmptyValues, mockEmptyValues, []Difference{}, true},
+		{"v2_empty", mockV2Ctx, mockValueFields, mockEmptyValues, mockEmptyValues, []Difference{}, true},
 		{
-			"v3_empty", mockV3Ctx, 2, mockEmptyValues, mockEmptyValues, mockColumns,
-			[]Difference{{"v1", "", ""}, {"v2", "", ""}},
+			"v3_empty", mockV3Ctx, mockValueFields, mockEmptyValues, mockEmptyValues,
+			[]Difference{{"v1", "", "", true}, {"v2", "", "", true}},
 			true,
 		},
+		{"v0_null", mockV0Ctx, mockValueFields, mockNullValues, mockNullValues, []Difference{}, true},
+		{
+			"v0_null_empty", mockV0Ctx, mockValueFields, mockNullValues, mockEmptyValues,
+			[]Difference{{"v1", "NULL", "", false}, {"v2", "NULL", "", false}},
+			false,
+		},
+		{"v0_null_empty_as_empty", mockV0NullCtx, mockValueFields, mockNullValues, mockEmptyValues, []Difference{}, true},
+		{
+			"v0_left_row_absent", mockV0Ctx, mockValueFields, nil, mockValues,
+			[]Difference{{"v1", "", mockS1, false}, {"v2", "", mockS2, false}},
+			false,
+		},
+		{
+			"v0_right_row_absent", mockV0Ctx, mockValueFields, mockNullValues, nil,
+			[]Difference{{"v1", "NULL", "", false}, {"v2", "NULL", "", false}},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		var (
@@ -125,7 +152,7 @@ func TestGetDifferences(t *testing.T) {
 		)
 		t.Run(tt.name, func(t *testing.T) {
 			getDifferences(
-				tt.ctx, tt.fieldsNum, tt.values1, tt.values2, tt.columns, &actualDifferences, &actualEqual,
+				tt.ctx, tt.fields, tt.values1, tt.values2, &actualDifferences, &actualEqual,
 			)
 			require.Equal(t, tt.expectedDifferences, actualDifferences)
 			require.Equal(t, tt.expectedEqual, actualEqual)
@@ -156,6 +183,7 @@ func TestFormatDifferences(t *testing.T) {
 		{"v3_left_empty", mockV3Ctx, mockS1Empty, mockS1Empty.asLongOutput()},
 		{"v3_right_empty", mockV3Ctx, mockS2Empty, mockS2Empty.asLongOutput()},
 		{"v3_different", mockV3Ctx, mockDiff, mockDiff.asLongOutput()},
+		{"v3_equal_representations", mockV3Ctx, mockEqual, mockEqual.asLongOutput()},
 	}
 	for _, tt := range tests {
 		var actualResult string
@@ -0,0 +1,328 @@
+package dbdiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// sqliteAutoindexPrefix prefixes names of SQLite indexes created by UNIQUE constraints.
+const sqliteAutoindexPrefix = "sqlite_autoindex_"
+
+// columnTypes maps field types of a database of one type to the ones of a database of another type.
+// The outer map is keyed by the target database type name. Types not listed are kept as is.
+var columnTypes = map[string]map[string]string{
+	"postgres": {
+		"real":      "double precision",
+		"double":    "double precision",
+		"float":     "double precision",
+		"blob":      "bytea",
+		"datetime":  "timestamp",
+		"tinyint":   "smallint",
+		"mediumint": "integer",
+	},
+	"sqlite": {
+		"character varying":           "text",
+		"character":                   "text",
+		"double precision":            "real",
+		"bytea":                       "blob",
+		"timestamp without time zone": "timestamp",
+		"timestamp with time zone":    "timestamptz",
+		"time without time zone":      "time",
+		"time with time zone":         "timetz",
+		"json":                        "text",
+		"jsonb":                       "text",
+		"uuid":                        "text",
+	},
+}
+
+// columnTypeAliases maps PostgreSQL column types aliases to the names PostgreSQL schemas hold types with.
+var columnTypeAliases = map[string]string{
+	"int":         "integer",
+	"int2":        "smallint",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"serial":      "integer",
+	"bigserial":   "bigint",
+	"float4":      "real",
+	"float8":      "double precision",
+	"decimal":     "numeric",
+	"bool":        "boolean",
+	"char":        "character",
+	"varchar":     "character varying",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time":        "time without time zone",
+	"timetz":      "time with time zone",
+}
+
+// schemaScript builds statements turning database2 tables schemas into database1 ones, in database2 dialect.
+// Destructive statements, i.e. the ones which may lose data, are commented out unless allowed.
+// Changes not supported by database2 are described in comments.
+type schemaScript struct {
+	source, target models.DatabaseType
+	destructive    bool
+	statements     []string
+}
+
+// newSchemaScript returns a new schemaScript object.
+func newSchemaScript(source, target models.DatabaseType, destructive bool) *schemaScript {
+	return &schemaScript{source: source, target: target, destructive: destructive}
+}
+
+// add adds a statement. Destructive statements are commented out unless allowed.
+func (s *schemaScript) add(destructive bool, format string, args ...any) {
+	statement := fmt.Sprintf(format, args...)
+	if destructive && !s.destructive {
+		statement = "-- " + statement
+	}
+	s.statements = append(s.statements, statement)
+}
+
+// note adds a comment.
+func (s *schemaScript) note(format string, args ...any) {
+	s.statements = append(s.statements, "-- "+fmt.Sprintf(format, args...))
+}
+
+// quote quotes an identifier in the target database dialect.
+func (s *schemaScript) quote(name string) string {
+	return s.target.QuoteIdentifier(name)
+}
+
+// columnType returns a field type in the target database dialect.
+func (s *schemaScript) columnType(f *models.Field) string {
+	columnType := s.source.ColumnType(f)
+	if dialectOf(s.source) == dialectOf(s.target) {
+		return columnType
+	}
+	types := columnTypes[s.target.Name()]
+	if t, ok := types[columnType]; ok {
+		return t
+	}
+	base, _, _ := strings.Cut(columnType, "(")
+	if t, ok := types[base]; ok {
+		return t
+	}
+	return columnType
+}
+
+// createTable adds statements creating a given database1 table with its indexes.
+func (s *schemaScript) createTable(t *models.Table) {
+	var (
+		definitions []string
+		primaryKey  []string
+	)
+	for _, f := range t.Fields {
+		if f.PrimaryKey {
+			primaryKey = append(primaryKey, s.quote(f.Name))
+		}
+	}
+	for _, f := range t.Fields {
+		definition := fmt.Sprintf("%s %s", s.quote(f.Name), s.columnType(f))
+		if f.PrimaryKey && len(primaryKey) == 1 {
+			definition += " PRIMARY KEY"
+		}
+		if f.NotNull && !f.PrimaryKey {
+			definition += " NOT NULL"
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(primaryKey) > 1 {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
+	}
+	s.add(false, "CREATE TABLE %s (\n\t%s\n);", s.quote(t.Name), strings.Join(definitions, ",\n\t"))
+
+	for _, index := range t.Indexes {
+		s.createIndex(t.Name, index)
+	}
+}
+
+// dropTable adds a statement dropping a database2 table with a given name.
+func (s *schemaScript) dropTable(name string) {
+	s.add(true, "DROP TABLE %s;", s.quote(name))
+}
+
+// alterTable adds statements turning a database2 table schema into a database1 one.
+// Fields ignored in comparison are not taken into account.
+func (s *schemaScript) alterTable(t1, t2 *models.Table) {
+	table := s.quote(t1.Name)
+	var (
+		primaryKey []string // database1 table primary key columns
+		addKey     bool     // whether any of the columns is not a database2 table primary key one
+		hasKey     bool     // whether database2 table has a primary key
+	)
+	for _, f := range t2.Fields {
+		hasKey = hasKey || f.PrimaryKey
+	}
+	for _, f := range t1.Fields {
+		if f.PrimaryKey {
+			primaryKey = append(primaryKey, s.quote(f.Name))
+			i, exists := t2.FieldNameIndex[f.Name]
+			addKey = addKey || !exists || !t2.Fields[i].PrimaryKey
+		}
+	}
+
+	for _, f := range t1.Fields {
+		i, exists := t2.FieldNameIndex[f.Name]
+		if !exists {
+			s.add(false, "ALTER TABLE %s ADD COLUMN %s %s;", table, s.quote(f.Name), s.columnType(f))
+			continue
+		}
+		f2 := t2.Fields[i]
+		if !s.sameColumnType(s.columnType(f), s.target.ColumnType(f2)) {
+			s.alterColumnType(t1.Name, f, f2)
+		}
+		if f.NotNull != f2.NotNull && !f.PrimaryKey && !f2.PrimaryKey {
+			s.alterColumnNotNull(t1.Name, f)
+		}
+	}
+	// a composite primary key is added at once
+	if addKey {
+		s.addPrimaryKey(t1.Name, primaryKey, hasKey)
+	}
+	for _, f := range t2.Fields {
+		if _, exists := t1.FieldNameIndex[f.Name]; !exists {
+			s.add(true, "ALTER TABLE %s DROP COLUMN %s;", table, s.quote(f.Name))
+		}
+	}
+
+	// indexes are matched by their definitions, so that differently named equivalent indexes are not recreated
+	definitions1 := make(map[string]struct{}, len(t1.Indexes))
+	for _, index := range t1.Indexes {
+		definitions1[index.Definition()] = struct{}{}
+	}
+	definitions2 := make(map[string]struct{}, len(t2.Indexes))
+	for _, index := range t2.Indexes {
+		definitions2[index.Definition()] = struct{}{}
+		if _, ok := definitions1[index.Definition()]; !ok {
+			s.dropIndex(t2.Name, index)
+		}
+	}
+	for _, index := range t1.Indexes {
+		if _, ok := definitions2[index.Definition()]; !ok {
+			s.createIndex(t1.Name, index)
+		}
+	}
+}
+
+// sameColumnType reports whether given column types in the target database dialect are the same. PostgreSQL types
+// aliases are resolved. Type modifiers, e.g. length, are compared only if both types have them, as PostgreSQL schemas
+// hold types without modifiers.
+func (s *schemaScript) sameColumnType(type1, type2 string) bool {
+	base1, modifiers1 := s.splitColumnType(type1)
+	base2, modifiers2 := s.splitColumnType(type2)
+	return base1 == base2 && (len(modifiers1) == 0 || len(modifiers2) == 0 || modifiers1 == modifiers2)
+}
+
+// splitColumnType returns a given column type without modifiers, with PostgreSQL aliases resolved, and its modifiers.
+// Arrays types keep their [] suffix.
+func (s *schemaScript) splitColumnType(columnType string) (string, string) {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	var array string
+	if strings.HasSuffix(columnType, "[]") {
+		columnType, array = strings.TrimSuffix(columnType, "[]"), "[]"
+	}
+	var modifiers string
+	if start := strings.Index(columnType, "("); start >= 0 {
+		if end := strings.Index(columnType[start:], ")"); end >= 0 {
+			modifiers = columnType[start : start+end+1]
+			columnType = columnType[:start] + columnType[start+end+1:]
+		}
+	}
+	columnType = strings.Join(strings.Fields(columnType), " ")
+	if s.target.Name() == postgresql.Name() {
+		if alias, ok := columnTypeAliases[columnType]; ok {
+			columnType = alias
+		}
+	}
+	return columnType + array, modifiers
+}
+
+// alterColumnType adds a statement changing a database2 table field type to the one of a database1 field.
+func (s *schemaScript) alterColumnType(table string, f1, f2 *models.Field) {
+	columnType := s.columnType(f1)
+	if s.target.Name() == sqlite.Name() {
+		s.note(
+			"column %s of table %s type cannot be changed from %s to %s without rebuilding the table",
+			s.quote(f1.Name), s.quote(table), f2.FieldType, columnType,
+		)
+		return
+	}
+	s.add(
+		true, "ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;",
+		s.quote(table), s.quote(f1.Name), columnType, s.quote(f1.Name), columnType,
+	)
+}
+
+// alterColumnNotNull adds a statement adding or dropping a database2 table field NOT NULL constraint,
+// as the one of a database1 field is declared.
+func (s *schemaScript) alterColumnNotNull(table string, f1 *models.Field) {
+	action := "DROP"
+	if f1.NotNull {
+		action = "SET"
+	}
+	if s.target.Name() == sqlite.Name() {
+		s.note(
+			"column %s of table %s NOT NULL constraint cannot be %s without rebuilding the table",
+			s.quote(f1.Name), s.quote(table), map[bool]string{true: "added", false: "dropped"}[f1.NotNull],
+		)
+		return
+	}
+	s.add(false, "ALTER TABLE %s ALTER COLUMN %s %s NOT NULL;", s.quote(table), s.quote(f1.Name), action)
+}
+
+// addPrimaryKey adds a statement making given quoted columns a database2 table primary key. A given flag indicates
+// whether the table has another primary key, which name is not known, so that it cannot be dropped.
+func (s *schemaScript) addPrimaryKey(table string, columns []string, replaced bool) {
+	key := strings.Join(columns, ", ")
+	switch {
+	case s.target.Name() == sqlite.Name():
+		s.note("primary key (%s) cannot be added to table %s without rebuilding the table", key, s.quote(table))
+	case replaced:
+		s.note("primary key of table %s cannot be changed to (%s) without dropping the existing one", s.quote(table), key)
+	default:
+		s.add(false, "ALTER TABLE %s ADD PRIMARY KEY (%s);", s.quote(table), key)
+	}
+}
+
+// createIndex adds a statement creating an index on a database2 table. Unique constraints are added as constraints
+// if supported by database2. Names of SQLite indexes created by UNIQUE constraints are replaced with generated ones.
+func (s *schemaScript) createIndex(table string, index *models.Index) {
+	name := index.Name
+	if strings.HasPrefix(name, sqliteAutoindexPrefix) {
+		name = fmt.Sprintf("%s_%s_key", table, strings.Join(index.Columns, "_"))
+	}
+	columns := make([]string, 0, len(index.Columns))
+	for _, c := range index.Columns {
+		columns = append(columns, s.quote(c))
+	}
+
+	if index.Constraint && s.target.Name() != sqlite.Name() {
+		s.add(
+			false, "ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s);",
+			s.quote(table), s.quote(name), strings.Join(columns, ", "),
+		)
+		return
+	}
+	var unique string
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	s.add(false, "CREATE %sINDEX %s ON %s (%s);", unique, s.quote(name), s.quote(table), strings.Join(columns, ", "))
+}
+
+// dropIndex adds a statement dropping a database2 table index.
+func (s *schemaScript) dropIndex(table string, index *models.Index) {
+	switch {
+	case strings.HasPrefix(index.Name, sqliteAutoindexPrefix):
+		s.note(
+			"unique constraint %s of table %s cannot be dropped without rebuilding the table",
+			index.Definition(), s.quote(table),
+		)
+	case index.Constraint:
+		s.add(false, "ALTER TABLE %s DROP CONSTRAINT %s;", s.quote(table), s.quote(index.Name))
+	default:
+		s.add(false, "DROP INDEX %s;", s.quote(index.Name))
+	}
+}
@@ -0,0 +1,210 @@
+package dbdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// newDDLTable returns a table with given fields definitions and indexes, parsed as SQLite ones.
+func newDDLTable(name string, fields []string, indexes ...*models.Index) *models.Table {
+	t := &models.Table{Name: name, FieldNameIndex: make(map[string]int), Indexes: indexes}
+	for _, f := range fields {
+		t.AddField(f)
+	}
+	return t
+}
+
+func TestSchemaScriptCreateTable(t *testing.T) {
+	table := newDDLTable(
+		"users",
+		[]string{"id integer primary key", "name text not null", "score real"},
+		&models.Index{Name: "sqlite_autoindex_users_1", Columns: []string{"name"}, Unique: true, Constraint: true},
+	)
+
+	var tests = []struct {
+		target             models.DatabaseType
+		expectedStatements []string
+	}{
+		{
+			sqlite,
+			[]string{
+				"CREATE TABLE \"users\" (\n\t\"id\" integer PRIMARY KEY,\n\t\"name\" text NOT NULL,\n\t\"score\" real\n);",
+				`CREATE UNIQUE INDEX "users_name_key" ON "users" ("name");`,
+			},
+		},
+		{
+			postgresql,
+			[]string{
+				"CREATE TABLE \"users\" (\n\t\"id\" integer PRIMARY KEY,\n\t\"name\" text NOT NULL,\n\t\"score\" double precision\n);",
+				`ALTER TABLE "users" ADD CONSTRAINT "users_name_key" UNIQUE ("name");`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target.Name(), func(t *testing.T) {
+			s := newSchemaScript(sqlite, tt.target, false)
+			s.createTable(table)
+			require.Equal(t, tt.expectedStatements, s.statements)
+		})
+	}
+}
+
+func TestSchemaScriptAlterTable(t *testing.T) {
+	t1 := newDDLTable(
+		"users",
+		[]string{"id integer primary key", "name text", "age integer"},
+		&models.Index{Name: "users_name", Columns: []string{"name"}},
+	)
+	t2 := newDDLTable(
+		"users",
+		[]string{"id integer primary key", "name varchar", "legacy text"},
+		&models.Index{Name: "users_legacy", Columns: []string{"legacy"}},
+	)
+
+	var tests = []struct {
+		name               string
+		target             models.DatabaseType
+		destructive        bool
+		expectedStatements []string
+	}{
+		{
+			"sqlite", sqlite, false,
+			[]string{
+				`-- column "name" of table "users" type cannot be changed from varchar to text without rebuilding the table`,
+				`ALTER TABLE "users" ADD COLUMN "age" integer;`,
+				`-- ALTER TABLE "users" DROP COLUMN "legacy";`,
+				`DROP INDEX "users_legacy";`,
+				`CREATE INDEX "users_name" ON "users" ("name");`,
+			},
+		},
+		{
+			"postgres", postgresql, false,
+			[]string{
+				`-- ALTER TABLE "users" ALTER COLUMN "name" TYPE text USING "name"::text;`,
+				`ALTER TABLE "users" ADD COLUMN "age" integer;`,
+				`-- ALTER TABLE "users" DROP COLUMN "legacy";`,
+				`DROP INDEX "users_legacy";`,
+				`CREATE INDEX "users_name" ON "users" ("name");`,
+			},
+		},
+		{
+			"postgres destructive", postgresql, true,
+			[]string{
+				`ALTER TABLE "users" ALTER COLUMN "name" TYPE text USING "name"::text;`,
+				`ALTER TABLE "users" ADD COLUMN "age" integer;`,
+				`ALTER TABLE "users" DROP COLUMN "legacy";`,
+				`DROP INDEX "users_legacy";`,
+				`CREATE INDEX "users_name" ON "users" ("name");`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSchemaScript(sqlite, tt.target, tt.destructive)
+			s.alterTable(t1, t2)
+			require.Equal(t, tt.expectedStatements, s.statements)
+		})
+	}
+}
+
+func TestSchemaScriptAlterColumns(t *testing.T) {
+	// newPostgresqlTable returns a table with a given PostgreSQL schema
+	newPostgresqlTable := func(schema string) *models.Table {
+		table := &models.Table{Name: "events", Schema: schema, FieldNameIndex: make(map[string]int)}
+		postgresql.Parse(table)
+		return table
+	}
+
+	var tests = []struct {
+		name               string
+		source             models.DatabaseType
+		t1, t2             *models.Table
+		target             models.DatabaseType
+		expectedStatements []string
+	}{
+		{
+			"postgres_attributes",
+			postgresql,
+			newPostgresqlTable("id integer PRIMARY KEY NOT NULL, at timestamp with time zone , code character varying "),
+			newPostgresqlTable("id integer PRIMARY KEY NOT NULL, at timestamp without time zone , code character "),
+			postgresql,
+			[]string{
+				`-- ALTER TABLE "events" ALTER COLUMN "at" TYPE timestamp with time zone USING "at"::timestamp with time zone;`,
+				`-- ALTER TABLE "events" ALTER COLUMN "code" TYPE character varying USING "code"::character varying;`,
+			},
+		},
+		{
+			"postgres_not_null",
+			postgresql,
+			newPostgresqlTable("id integer PRIMARY KEY NOT NULL, a text  NOT NULL, b text "),
+			newPostgresqlTable("id integer PRIMARY KEY NOT NULL, a text , b text  NOT NULL"),
+			postgresql,
+			[]string{
+				`ALTER TABLE "events" ALTER COLUMN "a" SET NOT NULL;`,
+				`ALTER TABLE "events" ALTER COLUMN "b" DROP NOT NULL;`,
+			},
+		},
+		{
+			"postgres_arrays",
+			postgresql,
+			newPostgresqlTable("id integer PRIMARY KEY NOT NULL, tags ARRAY text , scores ARRAY int4 "),
+			newPostgresqlTable("id integer PRIMARY KEY NOT NULL, tags ARRAY varchar , scores ARRAY int4 "),
+			postgresql,
+			[]string{`-- ALTER TABLE "events" ALTER COLUMN "tags" TYPE text[] USING "tags"::text[];`},
+		},
+		{
+			"sqlite_to_postgres_aliases",
+			sqlite,
+			newDDLTable("events", []string{"id integer primary key", "at timestamp not null", "code varchar(10)", "n int"}),
+			newPostgresqlTable("id integer PRIMARY KEY NOT NULL, at timestamp without time zone  NOT NULL, code character varying , n integer "),
+			postgresql,
+			nil,
+		},
+		{
+			"postgres_composite_primary_key",
+			postgresql,
+			newPostgresqlTable("a integer PRIMARY KEY NOT NULL, b integer PRIMARY KEY NOT NULL, c text "),
+			newPostgresqlTable("a integer  NOT NULL, c text "),
+			postgresql,
+			[]string{
+				`ALTER TABLE "events" ADD COLUMN "b" integer;`,
+				`ALTER TABLE "events" ADD PRIMARY KEY ("a", "b");`,
+			},
+		},
+		{
+			"postgres_changed_primary_key",
+			postgresql,
+			newPostgresqlTable("a integer PRIMARY KEY NOT NULL, b integer PRIMARY KEY NOT NULL"),
+			newPostgresqlTable("a integer PRIMARY KEY NOT NULL, b integer  NOT NULL"),
+			postgresql,
+			[]string{`-- primary key of table "events" cannot be changed to ("a", "b") without dropping the existing one`},
+		},
+		{
+			"sqlite_not_null",
+			sqlite,
+			newDDLTable("events", []string{"id integer primary key", "a text not null"}),
+			newDDLTable("events", []string{"id integer primary key", "a text"}),
+			sqlite,
+			[]string{`-- column "a" of table "events" NOT NULL constraint cannot be added without rebuilding the table`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSchemaScript(tt.source, tt.target, false)
+			s.alterTable(tt.t1, tt.t2)
+			require.Equal(t, tt.expectedStatements, s.statements)
+		})
+	}
+}
+
+func TestSchemaScriptDropTable(t *testing.T) {
+	s := newSchemaScript(sqlite, sqlite, false)
+	s.dropTable("old")
+	require.Equal(t, []string{`-- DROP TABLE "old";`}, s.statements)
+}
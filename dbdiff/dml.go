@@ -0,0 +1,230 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// dataSQLHeader is a header of a script turning database2 data into database1 one.
+const dataSQLHeader = "-- Statements turning database2 data into database1 one, ordered by foreign keys dependencies."
+
+// dataPatch holds statements turning database2 table data into database1 one, in database2 dialect:
+// inserts of rows missing from database2, updates of changed fields and deletes of rows which exist in database2 only.
+// Rows are identified by primary key values. Fields ignored in comparison are neither inserted, nor updated.
+type dataPatch struct {
+	table          *models.Table
+	source, target models.DatabaseType
+	inserts        []string
+	updates        []string
+	deletes        []string
+	// references holds names of tables referenced by database2 table foreign keys.
+	references []string
+	// notes holds comments describing why the patch is incomplete, if so.
+	notes []string
+}
+
+// newDataPatch returns a new dataPatch object for a given compared table.
+func newDataPatch(t *models.Table, source, target models.DatabaseType) *dataPatch {
+	return &dataPatch{table: t, source: source, target: target}
+}
+
+// empty reports whether the patch holds no statements.
+func (p *dataPatch) empty() bool {
+	return len(p.inserts) == 0 && len(p.updates) == 0 && len(p.deletes) == 0
+}
+
+// note adds a comment.
+func (p *dataPatch) note(format string, args ...any) {
+	p.notes = append(p.notes, "-- "+fmt.Sprintf(format, args...))
+}
+
+// quote quotes an identifier in the target database dialect.
+func (p *dataPatch) quote(name string) string {
+	return p.target.QuoteIdentifier(name)
+}
+
+// primaryKeyIndex returns an index of the table primary key field in table fields.
+func (p *dataPatch) primaryKeyIndex() int {
+	for i, f := range p.table.Fields {
+		if f == p.table.PrimaryKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// insert adds a statement inserting a database1 row with given raw and canonical values.
+func (p *dataPatch) insert(ctx context.Context, raw, values []sql.NullString) {
+	columns := make([]string, 0, len(p.table.Fields))
+	literals := make([]string, 0, len(p.table.Fields))
+	for i, f := range p.table.Fields {
+		columns = append(columns, p.quote(f.Name))
+		literals = append(literals, p.literal(ctx, f, raw[i], values[i], p.source))
+	}
+	p.inserts = append(p.inserts, fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s);",
+		p.quote(p.table.Name), strings.Join(columns, ", "), strings.Join(literals, ", "),
+	))
+}
+
+// update adds a statement setting database2 row fields values, which differ from database1 ones, to the latter.
+// Database1 row raw and canonical values and database2 row canonical values are given.
+func (p *dataPatch) update(ctx context.Context, raw1, values1, values2 []sql.NullString) {
+	nullAsEmpty := fromContext(ctx, NullAsEmptyContextKey, false)
+	var assignments []string
+	for i, f := range p.table.Fields {
+		value1, _ := valueAt(values1, i, nullAsEmpty)
+		value2, _ := valueAt(values2, i, nullAsEmpty)
+		if equal, _ := compareValues(ctx, f, value1, value2); !equal {
+			assignments = append(
+				assignments,
+				fmt.Sprintf("%s = %s", p.quote(f.Name), p.literal(ctx, f, raw1[i], values1[i], p.source)),
+			)
+		}
+	}
+	if len(assignments) == 0 {
+		return
+	}
+	pk := p.primaryKeyIndex()
+	p.updates = append(p.updates, fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s = %s;",
+		p.quote(p.table.Name), strings.Join(assignments, ", "), p.quote(p.table.PrimaryKey.Name),
+		p.literal(ctx, p.table.PrimaryKey, raw1[pk], values1[pk], p.source),
+	))
+}
+
+// delete adds a statement deleting a database2 row with a given raw primary key value.
+func (p *dataPatch) delete(ctx context.Context, pk sql.NullString) {
+	p.deletes = append(p.deletes, fmt.Sprintf(
+		"DELETE FROM %s WHERE %s = %s;",
+		p.quote(p.table.Name), p.quote(p.table.PrimaryKey.Name), p.literal(ctx, p.table.PrimaryKey, pk, pk, p.target),
+	))
+}
+
+// literal returns a given field value as an SQL literal in the target database dialect. Values retrieved from
+// a database of the target type are used as is. Otherwise, canonical values are used, so that, e.g., timestamps
+// and booleans are converted. Binary values are represented in hex.
+func (p *dataPatch) literal(ctx context.Context, f *models.Field, raw, value sql.NullString, dbType models.DatabaseType) string {
+	isSqlite := p.target.Name() == sqlite.Name()
+	switch {
+	case !raw.Valid:
+		return "NULL"
+	case kindOf(ctx, f) == binaryKind:
+		if isSqlite {
+			return fmt.Sprintf("X'%s'", hex.EncodeToString([]byte(raw.String)))
+		}
+		return fmt.Sprintf(`'\x%s'`, hex.EncodeToString([]byte(raw.String)))
+	case dbType.Name() == p.target.Name():
+		return quoteLiteral(raw.String)
+	case isSqlite && (f.FieldType == "boolean" || f.FieldType == "bool") && value.String == "true":
+		return "1"
+	case isSqlite && (f.FieldType == "boolean" || f.FieldType == "bool") && value.String == "false":
+		return "0"
+	default:
+		return quoteLiteral(value.String)
+	}
+}
+
+// orderByReferences returns names of tables of given patches ordered so that referenced tables precede
+// the referencing ones. Tables are otherwise ordered by name. Tables referencing each other are ordered by name.
+func orderByReferences(patches map[string]*dataPatch) []string {
+	names := make([]string, 0, len(patches))
+	for name := range patches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ordered := make([]string, 0, len(names))
+	added := make(map[string]bool, len(names))
+	// ready reports whether all the tables referenced by a given one, which have patches, are already added
+	ready := func(name string) bool {
+		for _, reference := range patches[name].references {
+			if _, ok := patches[reference]; ok && reference != name && !added[reference] {
+				return false
+			}
+		}
+		return true
+	}
+	for len(ordered) < len(names) {
+		next := ""
+		for _, name := range names {
+			if !added[name] && ready(name) {
+				next = name
+				break
+			}
+		}
+		if len(next) == 0 {
+			// tables references form a cycle, the first remaining table is taken
+			for _, name := range names {
+				if !added[name] {
+					next = name
+					break
+				}
+			}
+		}
+		added[next] = true
+		ordered = append(ordered, next)
+	}
+	return ordered
+}
+
+// patchSection is a group of statements of a single table.
+type patchSection struct {
+	table      string
+	statements []string
+}
+
+// patchSections returns statements of given patches grouped by table and ordered so that rows are inserted
+// and updated in referenced tables first and deleted in referencing tables first. Notes precede statements.
+func patchSections(patches map[string]*dataPatch) []patchSection {
+	ordered := orderByReferences(patches)
+	var sections []patchSection
+	add := func(name string, statements []string) {
+		if len(statements) > 0 {
+			sections = append(sections, patchSection{table: name, statements: statements})
+		}
+	}
+	for _, name := range ordered {
+		add(name, patches[name].notes)
+	}
+	for _, name := range ordered {
+		add(name, patches[name].inserts)
+	}
+	for _, name := range ordered {
+		add(name, patches[name].updates)
+	}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		add(ordered[i], patches[ordered[i]].deletes)
+	}
+	return sections
+}
+
+// deferForeignKeysSQL returns a statement deferring foreign keys checks till a transaction commit
+// in a given database type dialect. PostgreSQL defers checks of deferrable constraints only.
+func deferForeignKeysSQL(dbType models.DatabaseType) string {
+	if dbType.Name() == sqlite.Name() {
+		return "PRAGMA defer_foreign_keys = ON;"
+	}
+	return "SET CONSTRAINTS ALL DEFERRED;"
+}
+
+// writeDataPatch writes statements of given patches, in a target database type dialect, as a single transaction.
+func writeDataPatch(w io.Writer, target models.DatabaseType, patches map[string]*dataPatch) error {
+	if _, err := fmt.Fprintf(w, "%s\nBEGIN;\n%s\n", dataSQLHeader, deferForeignKeysSQL(target)); err != nil {
+		return err
+	}
+	for _, section := range patchSections(patches) {
+		if _, err := fmt.Fprintf(w, "\n-- Table %s\n%s\n", section.table, strings.Join(section.statements, "\n")); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "\nCOMMIT;")
+	return err
+}
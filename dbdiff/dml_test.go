@@ -0,0 +1,139 @@
+package dbdiff
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// nullStrings returns given values as valid NullString values, except "NULL" ones.
+func nullStrings(values ...string) []sql.NullString {
+	result := make([]sql.NullString, 0, len(values))
+	for _, v := range values {
+		result = append(result, sql.NullString{String: v, Valid: v != "NULL"})
+	}
+	return result
+}
+
+func TestDataPatchStatements(t *testing.T) {
+	ctx := context.Background()
+	table := newDDLTable("users", []string{"active boolean", "id integer primary key", "name text", "pic blob"})
+
+	var tests = []struct {
+		name               string
+		source, target     models.DatabaseType
+		raw1, values1      []sql.NullString
+		expectedStatements []string
+	}{
+		{
+			"same types", sqlite, sqlite,
+			nullStrings("1", "2", "o'b", "\x01\x02"),
+			nullStrings("true", "2", "o'b", "\x01\x02"),
+			[]string{
+				`INSERT INTO "users" ("active", "id", "name", "pic") VALUES ('1', '2', 'o''b', X'0102');`,
+				`UPDATE "users" SET "active" = '1', "name" = 'o''b' WHERE "id" = '2';`,
+				`DELETE FROM "users" WHERE "id" = '5';`,
+			},
+		},
+		{
+			"postgres to sqlite", postgresql, sqlite,
+			nullStrings("t", "2", "o'b", "\x01\x02"),
+			nullStrings("true", "2", "o'b", "\x01\x02"),
+			[]string{
+				`INSERT INTO "users" ("active", "id", "name", "pic") VALUES (1, '2', 'o''b', X'0102');`,
+				`UPDATE "users" SET "active" = 1, "name" = 'o''b' WHERE "id" = '2';`,
+				`DELETE FROM "users" WHERE "id" = '5';`,
+			},
+		},
+		{
+			"sqlite to postgres", sqlite, postgresql,
+			nullStrings("1", "2", "o'b", "NULL"),
+			nullStrings("true", "2", "o'b", "NULL"),
+			[]string{
+				`INSERT INTO "users" ("active", "id", "name", "pic") VALUES ('true', '2', 'o''b', NULL);`,
+				`UPDATE "users" SET "active" = 'true', "name" = 'o''b' WHERE "id" = '2';`,
+				`DELETE FROM "users" WHERE "id" = '5';`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := newDataPatch(table, tt.source, tt.target)
+			patch.insert(ctx, tt.raw1, tt.values1)
+			patch.update(ctx, tt.raw1, tt.values1, nullStrings("false", "2", "ob", tt.values1[3].String))
+			patch.delete(ctx, sql.NullString{String: "5", Valid: true})
+			require.Equal(t, tt.expectedStatements, append(append(patch.inserts, patch.updates...), patch.deletes...))
+		})
+	}
+}
+
+func TestOrderByReferences(t *testing.T) {
+	var tests = []struct {
+		name       string
+		references map[string][]string
+		expected   []string
+	}{
+		{"no references", map[string][]string{"b": nil, "a": nil}, []string{"a", "b"}},
+		{
+			"chain",
+			map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil},
+			[]string{"c", "b", "a"},
+		},
+		{
+			"self reference and table without patch",
+			map[string][]string{"a": {"a", "z"}, "b": {"a"}},
+			[]string{"a", "b"},
+		},
+		{
+			"cycle",
+			map[string][]string{"a": {"b"}, "b": {"a"}, "c": {"b"}},
+			[]string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := make(map[string]*dataPatch)
+			for name, references := range tt.references {
+				patches[name] = &dataPatch{references: references}
+			}
+			require.Equal(t, tt.expected, orderByReferences(patches))
+		})
+	}
+}
+
+func TestWriteDataPatch(t *testing.T) {
+	patches := map[string]*dataPatch{
+		"orders": {
+			inserts:    []string{"INSERT orders;"},
+			deletes:    []string{"DELETE orders;"},
+			references: []string{"users"},
+		},
+		"users": {
+			inserts: []string{"INSERT users;"},
+			updates: []string{"UPDATE users;"},
+			deletes: []string{"DELETE users;"},
+			notes:   []string{"-- note"},
+		},
+	}
+	var out bytes.Buffer
+	require.NoError(t, writeDataPatch(&out, postgresql, patches))
+	require.Equal(
+		t,
+		dataSQLHeader+"\nBEGIN;\nSET CONSTRAINTS ALL DEFERRED;\n"+
+			"\n-- Table users\n-- note\n"+
+			"\n-- Table users\nINSERT users;\n"+
+			"\n-- Table orders\nINSERT orders;\n"+
+			"\n-- Table users\nUPDATE users;\n"+
+			"\n-- Table orders\nDELETE orders;\n"+
+			"\n-- Table users\nDELETE users;\n"+
+			"\nCOMMIT;\n",
+		out.String(),
+	)
+}
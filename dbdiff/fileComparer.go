@@ -27,7 +27,7 @@ func (fc *fileComparer) parse(s string, e any) {
 	}
 }
 
-func (fc *fileComparer) Compare(_ context.Context, s1 string, s2 string) {
+func (fc *fileComparer) Compare(ctx context.Context, s1 string, s2 string) bool {
 	var fp1, fp2 string
 	equal := true // Comparison result holder
 
@@ -54,7 +54,7 @@ func (fc *fileComparer) Compare(_ context.Context, s1 string, s2 string) {
 
 	// Scan two files at the same time
 	i := 0
-	for {
+	for ctx.Err() == nil {
 		i++
 		var f1line, f2line string
 		if f1 := f1Scanner.Scan(); f1 {
@@ -81,4 +81,5 @@ func (fc *fileComparer) Compare(_ context.Context, s1 string, s2 string) {
 	if equal {
 		fmt.Println("Files are equal")
 	}
+	return !equal
 }
@@ -0,0 +1,180 @@
+package dbdiff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// tablePredicate is an SQL condition restricting compared rows of tables with names matching a glob pattern.
+type tablePredicate struct {
+	pattern, condition string
+}
+
+// queryArgs collects query parameters.
+type queryArgs struct {
+	placeholder func(n int) string
+	values      []any
+}
+
+// newQueryArgs returns a new queryArgs object for a given database type.
+func newQueryArgs(dbType models.DatabaseType) *queryArgs {
+	return &queryArgs{placeholder: dbType.Placeholder}
+}
+
+// bind adds a parameter with a given value and returns its placeholder.
+func (a *queryArgs) bind(value any) string {
+	a.values = append(a.values, value)
+	return a.placeholder(len(a.values))
+}
+
+// globCondition translates a glob pattern into an SQL condition on a given column. The pattern is bound to a parameter.
+// Returns false if the pattern cannot be expressed in SQL.
+type globCondition func(column, pattern string, negate bool, args *queryArgs) (string, bool)
+
+// tableFilterSQL returns SQL conditions restricting given column to names of tables passing a given filter.
+// Each condition is prefixed with AND. Patterns which cannot be expressed in SQL are not pushed into the query,
+// so that the filter must also be applied to the query results.
+func tableFilterSQL(column string, filter *models.TableFilter, condition globCondition, args *queryArgs) string {
+	var sql string
+	if filter == nil {
+		return sql
+	}
+
+	includes := make([]string, 0, len(filter.Include))
+	bound := len(args.values)
+	for _, p := range filter.Include {
+		c, ok := condition(column, p, false, args)
+		if !ok {
+			// parameters of already translated patterns are not used
+			includes, args.values = nil, args.values[:bound]
+			break
+		}
+		includes = append(includes, c)
+	}
+	if len(includes) > 0 {
+		sql += fmt.Sprintf(" AND (%s)", strings.Join(includes, " OR "))
+	}
+
+	for _, p := range filter.Exclude {
+		if c, ok := condition(column, p, true, args); ok {
+			sql += " AND " + c
+		}
+	}
+	return sql
+}
+
+// quoteLiteral returns a given string as an SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifier returns a given name as an SQL quoted identifier.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// parseTablePredicates parses given predicates defined as "table=condition", where table is a table name glob pattern.
+// Each condition is validated, see [validatePredicate].
+func parseTablePredicates(entries []string) ([]tablePredicate, error) {
+	predicates := make([]tablePredicate, 0, len(entries))
+	for _, entry := range entries {
+		pattern, condition, ok := strings.Cut(entry, "=")
+		pattern, condition = strings.TrimSpace(pattern), strings.TrimSpace(condition)
+		if !ok || len(pattern) == 0 || len(condition) == 0 {
+			return nil, fmt.Errorf("invalid rows filter %q, expected format: table=condition", entry)
+		}
+		if err := validatePredicate(condition); err != nil {
+			return nil, fmt.Errorf("invalid rows filter %q: %w", entry, err)
+		}
+		predicates = append(predicates, tablePredicate{pattern: pattern, condition: condition})
+	}
+	return predicates, nil
+}
+
+// predicateFor returns a condition combining all the predicates applicable to a table with given name.
+func predicateFor(predicates []tablePredicate, table string) string {
+	var conditions []string
+	for _, p := range predicates {
+		if models.NewTableFilter([]string{p.pattern}, nil).Match(table) {
+			conditions = append(conditions, p.condition)
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	for i := range conditions {
+		conditions[i] = fmt.Sprintf("(%s)", conditions[i])
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// validatePredicate checks that given SQL condition cannot break out of the WHERE clause it is put in.
+// Statements separators, comments, backslashes in quoted strings, dollar signs, which start PostgreSQL dollar-quoted
+// strings, escape strings, quotes in brackets, which quote SQLite identifiers, unbalanced parentheses and brackets
+// and unterminated quotes are not allowed.
+func validatePredicate(condition string) error {
+	var (
+		quote    rune // quote character of a currently open quoted string or identifier
+		prev     rune
+		depth    int // parentheses nesting depth
+		brackets int // brackets nesting depth
+	)
+	for i, r := range condition {
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				return errors.New("backslashes are not allowed in quoted strings")
+			}
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' && (prev == 'e' || prev == 'E') && (i < 2 || !isIdentifierByte(condition[i-2])):
+			return errors.New("escape strings are not allowed")
+		case r == '\'' || r == '"' || r == '`':
+			if brackets > 0 {
+				return errors.New("quotes are not allowed in brackets")
+			}
+			quote = r
+		case r == '$':
+			return errors.New("dollar signs are not allowed outside quoted strings")
+		case r == ';':
+			return errors.New("statements separators are not allowed")
+		case (prev == '-' && r == '-') || (prev == '/' && r == '*') || (prev == '*' && r == '/'):
+			return errors.New("comments are not allowed")
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth--; depth < 0 {
+				return errors.New("unbalanced parentheses")
+			}
+		case r == '[':
+			brackets++
+		case r == ']':
+			if brackets--; brackets < 0 {
+				return errors.New("unbalanced brackets")
+			}
+		}
+		prev = r
+		if quote != 0 {
+			prev = 0
+		}
+	}
+	switch {
+	case quote != 0:
+		return errors.New("unterminated quoted string")
+	case depth != 0:
+		return errors.New("unbalanced parentheses")
+	case brackets != 0:
+		return errors.New("unbalanced brackets")
+	}
+	return nil
+}
+
+// isIdentifierByte reports whether a given byte can be a part of an unquoted identifier.
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b >= utf8.RuneSelf || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
@@ -0,0 +1,110 @@
+package dbdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+func TestTableFilterSQL(t *testing.T) {
+	var tests = []struct {
+		name         string
+		filter       *models.TableFilter
+		dbType       models.DatabaseType
+		condition    globCondition
+		expected     string
+		expectedArgs []any
+	}{
+		{"no_filter", nil, sqlite, sqliteGlobCondition, "", nil},
+		{"empty_filter", models.NewTableFilter(nil, nil), postgresql, postgresqlGlobCondition, "", nil},
+		{
+			"sqlite",
+			models.NewTableFilter([]string{"Orders*", "it[e]ms"}, []string{"*_audit"}),
+			sqlite,
+			sqliteGlobCondition,
+			" AND (lower(t) GLOB ? OR lower(t) GLOB ?) AND lower(t) NOT GLOB ?",
+			[]any{"orders*", "it[e]ms", "*_audit"},
+		},
+		{
+			"postgres",
+			models.NewTableFilter([]string{"orders*", "Item?"}, []string{"*_audit", "o'brien"}),
+			postgresql,
+			postgresqlGlobCondition,
+			` AND (lower(t) LIKE $1 ESCAPE '\' OR lower(t) LIKE $2 ESCAPE '\')` +
+				` AND lower(t) NOT LIKE $3 ESCAPE '\' AND lower(t) NOT LIKE $4 ESCAPE '\'`,
+			[]any{"orders%", "item_", `%\_audit`, "o'brien"},
+		},
+		{
+			"postgres_unsupported_include",
+			models.NewTableFilter([]string{"orders*", "it[e]ms"}, []string{"[a]udit", "tmp"}),
+			postgresql,
+			postgresqlGlobCondition,
+			` AND lower(t) NOT LIKE $1 ESCAPE '\'`,
+			[]any{"tmp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := newQueryArgs(tt.dbType)
+			require.Equal(t, tt.expected, tableFilterSQL("t", tt.filter, tt.condition, args))
+			require.Equal(t, tt.expectedArgs, args.values)
+		})
+	}
+}
+
+func TestValidatePredicate(t *testing.T) {
+	var tests = []struct {
+		name      string
+		condition string
+		valid     bool
+	}{
+		{"simple", "tenant_id = 42", true},
+		{"function", "created_at > now() - interval '7 days'", true},
+		{"nested_parentheses", "(a = 1 OR (b = 2 AND c IN (3, 4)))", true},
+		{"quoted_special_characters", `name = 'a;b--c/*d(' AND "odd;name" = 'x'`, true},
+		{"escaped_quote", "name = 'o''brien'", true},
+		{"statements_separator", "1 = 1; DROP TABLE orders", false},
+		{"line_comment", "1 = 1 -- comment", false},
+		{"block_comment", "1 = 1 /* comment */", false},
+		{"closing_parenthesis", "1 = 1) OR (1 = 1", false},
+		{"unclosed_parenthesis", "(1 = 1", false},
+		{"unterminated_string", "name = 'abc", false},
+		{"backslash_in_string", `name = E'\'' OR 1 = 1`, false},
+		{"escape_string", "name = E'abc'", false},
+		{"string_ending_with_e", "name='e'", true},
+		{"dollar_quoted_string", "x = $$'$$) OR 1=1; COMMIT; DROP TABLE t; --'", false},
+		{"tagged_dollar_quoted_string", "x = $q$'$q$ OR 1 = 1", false},
+		{"array_subscript", "tags[1] = 'a'", true},
+		{"quote_in_brackets", "x = [']; DROP TABLE t; --']", false},
+		{"backtick_quoted_identifier", "`odd;name` = 1", true},
+		{"unbalanced_brackets", "tags[1 = 'a'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePredicate(tt.condition)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestParseTablePredicates(t *testing.T) {
+	predicates, err := parseTablePredicates([]string{"orders=created_at > '2023-01-01'", "*=tenant_id = 42"})
+	require.NoError(t, err)
+	require.Equal(t, "(created_at > '2023-01-01') AND (tenant_id = 42)", predicateFor(predicates, "orders"))
+	require.Equal(t, "tenant_id = 42", predicateFor(predicates, "items"))
+
+	_, err = parseTablePredicates([]string{"tenant_id > 0"})
+	require.Error(t, err)
+	_, err = parseTablePredicates([]string{"orders=1 = 1; DELETE FROM orders"})
+	require.Error(t, err)
+	_, err = parseTablePredicates([]string{"t=x = $$'$$) OR 1=1; COMMIT; DROP TABLE t; --'"})
+	require.Error(t, err)
+}
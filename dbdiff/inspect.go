@@ -0,0 +1,96 @@
+package dbdiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// Inspector is a type capable of outputting an entity structure.
+type Inspector interface {
+	Inspect(ctx context.Context, entity string)
+}
+
+// NewDatabaseInspector returns a new object outputting database schemas.
+func NewDatabaseInspector() Inspector {
+	return &databaseComparer{}
+}
+
+// Inspect outputs a given database normalized schema, as it is compared: tables passing tables filters, ordered
+// by name, with their fields, except ignored ones, indexes and referenced tables.
+// The database is read in a single snapshot of data.
+func (dc *databaseComparer) Inspect(ctx context.Context, input string) {
+	dc.inspect(ctx, input, os.Stdout)
+}
+
+// inspect outputs a given database normalized schema to a given writer.
+func (dc *databaseComparer) inspect(ctx context.Context, input string, out io.Writer) {
+	var d models.Database
+	dc.parse(input, &d)
+	if err := dc.createHandler(ctx, &d, 1); err != nil {
+		log.Fatalln(err)
+	}
+	defer d.Handler.Close()
+	d.StatementTimeout = fromContext(ctx, StatementTimeoutContextKey, time.Duration(0))
+	if err := dc.beginSnapshot(ctx, &d, d.Label); err != nil {
+		log.Fatalln(err)
+	}
+	defer d.EndSnapshot()
+
+	tables, err := snapshotTables(ctx, d)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Fatalln("cannot get database schema:", redactError(err))
+		}
+		return
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+
+	columnFilter := models.NewColumnFilter(fromContext(ctx, IgnoreColumnsContextKey, []string(nil)))
+	for _, t := range tables {
+		if err = t.GetIndexes(ctx); err == nil {
+			err = t.GetReferences(ctx)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Fatalf("cannot get table %s schema: %v\n", t.Name, redactError(err))
+			}
+			return
+		}
+		t.IgnoreFields(columnFilter)
+		writeTableSchema(out, t)
+	}
+}
+
+// writeTableSchema writes a given table fields, indexes and referenced tables.
+func writeTableSchema(w io.Writer, t *models.Table) {
+	fmt.Fprintf(w, "%s:\n", t.ID())
+	tw := tabwriter.NewWriter(w, 5, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "    Field\tType\tAttributes")
+	for _, f := range t.Fields {
+		fmt.Fprintf(tw, "    %s\t%s\t%s\n", f.Name, f.FieldType, strings.TrimSpace(f.Attrs))
+	}
+	tw.Flush()
+
+	if len(t.Indexes) > 0 {
+		fmt.Fprintln(w, "  indexes:")
+		tw = tabwriter.NewWriter(w, 5, 0, 3, ' ', 0)
+		for _, index := range t.Indexes {
+			fmt.Fprintf(tw, "    %s\t%s\n", index.Name, index.Definition())
+		}
+		tw.Flush()
+	}
+	if len(t.References) > 0 {
+		fmt.Fprintf(w, "  references: %s\n", strings.Join(t.References, ", "))
+	}
+}
@@ -0,0 +1,37 @@
+package dbdiff
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectSqlite(t *testing.T) {
+	input := createSqliteDatabase(t, "d1.db", `
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER REFERENCES customers, updated TEXT);
+		CREATE TABLE customers (id INTEGER PRIMARY KEY, email TEXT NOT NULL);
+		CREATE TABLE audit (id INTEGER PRIMARY KEY);
+		CREATE UNIQUE INDEX customers_email ON customers (email);
+		CREATE INDEX orders_customer ON orders (customer_id);`,
+	)
+	ctx := context.WithValue(context.Background(), ExcludeTablesContextKey, []string{"audit"})
+	ctx = context.WithValue(ctx, IgnoreColumnsContextKey, []string{"updated"})
+
+	var out bytes.Buffer
+	(&databaseComparer{}).inspect(ctx, input, &out)
+	require.Equal(t, "Table customers:\n"+
+		"    Field   Type      Attributes\n"+
+		"    id      integer   primary key\n"+
+		"    email   text      not null\n"+
+		"  indexes:\n"+
+		"    customers_email   unique (email)\n"+
+		"Table orders:\n"+
+		"    Field         Type      Attributes\n"+
+		"    id            integer   primary key\n"+
+		"    customer_id   integer   references customers\n"+
+		"  indexes:\n"+
+		"    orders_customer   (customer_id)\n"+
+		"  references: customers\n", out.String())
+}
@@ -0,0 +1,144 @@
+package dbdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+)
+
+// jsonIdentifier matches object keys which can be used in JSON paths without quoting.
+var jsonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonDifferences structurally compares two JSON documents being values of a field with a given name.
+// Objects keys order, whitespace and numbers representations are not taken into account.
+// Each difference is identified by the field name followed by a JSON path, e.g. "address $.city.zip".
+// Returns false if any of the values is not a valid JSON document.
+func jsonDifferences(name, s1, s2 string) ([]Difference, bool) {
+	doc1, ok1 := decodeJSON(s1)
+	doc2, ok2 := decodeJSON(s2)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	var differences []Difference
+	compareJSON(name, "$", doc1, doc2, true, true, &differences)
+	return differences, true
+}
+
+// decodeJSON decodes a JSON document keeping numbers representations. Documents followed by any data other than
+// whitespace are not valid.
+func decodeJSON(s string) (any, bool) {
+	var doc any
+	decoder := json.NewDecoder(bytes.NewBufferString(s))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, false
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, false
+	}
+	return doc, true
+}
+
+// compareJSON recursively compares two JSON values located at a given path and adds their differences to a slice.
+// exists1 and exists2 indicate whether the values exist in the first and the second document.
+func compareJSON(name, path string, v1, v2 any, exists1, exists2 bool, differences *[]Difference) {
+	if exists1 && exists2 {
+		switch o1 := v1.(type) {
+		case map[string]any:
+			if o2, ok := v2.(map[string]any); ok {
+				for _, key := range jsonKeys(o1, o2) {
+					e1, in1 := o1[key]
+					e2, in2 := o2[key]
+					compareJSON(name, jsonObjectPath(path, key), e1, e2, in1, in2, differences)
+				}
+				return
+			}
+		case []any:
+			if a2, ok := v2.([]any); ok {
+				for i := 0; i < len(o1) || i < len(a2); i++ {
+					var e1, e2 any
+					if i < len(o1) {
+						e1 = o1[i]
+					}
+					if i < len(a2) {
+						e2 = a2[i]
+					}
+					compareJSON(name, fmt.Sprintf("%s[%d]", path, i), e1, e2, i < len(o1), i < len(a2), differences)
+				}
+				return
+			}
+		}
+		if jsonScalarsEqual(v1, v2) {
+			return
+		}
+	}
+
+	*differences = append(*differences, Difference{
+		Name:   fmt.Sprintf("%s %s", name, path),
+		Value1: formatJSON(v1, exists1),
+		Value2: formatJSON(v2, exists2),
+	})
+}
+
+// jsonKeys returns sorted union of two objects keys.
+func jsonKeys(o1, o2 map[string]any) []string {
+	keys := make([]string, 0, len(o1)+len(o2))
+	for k := range o1 {
+		keys = append(keys, k)
+	}
+	for k := range o2 {
+		if _, ok := o1[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonObjectPath returns a path of an object member with a given key.
+func jsonObjectPath(path, key string) string {
+	if jsonIdentifier.MatchString(key) {
+		return path + "." + key
+	}
+	quoted, _ := json.Marshal(key)
+	return fmt.Sprintf("%s[%s]", path, quoted)
+}
+
+// jsonScalarsEqual reports whether two JSON values are equal. Numbers are compared regardless of their representation.
+func jsonScalarsEqual(v1, v2 any) bool {
+	if n1, ok := v1.(json.Number); ok {
+		if n2, ok := v2.(json.Number); ok {
+			r1, ok1 := new(big.Rat).SetString(n1.String())
+			r2, ok2 := new(big.Rat).SetString(n2.String())
+			if ok1 && ok2 {
+				return r1.Cmp(r2) == 0
+			}
+			return n1 == n2
+		}
+		return false
+	}
+	switch v1.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	return v1 == v2
+}
+
+// formatJSON returns a compact representation of a JSON value used in comparison output.
+// A value which does not exist is represented as an empty string.
+func formatJSON(v any, exists bool) string {
+	if !exists {
+		return ""
+	}
+	var buff bytes.Buffer
+	encoder := json.NewEncoder(&buff)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(bytes.TrimRight(buff.Bytes(), "\n"))
+}
@@ -0,0 +1,97 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+func TestJSONDifferences(t *testing.T) {
+	var tests = []struct {
+		name                string
+		value1              string
+		value2              string
+		expectedDifferences []Difference
+		expectedOk          bool
+	}{
+		{"keys_order_whitespace", `{"a": 1, "b": [1, 2]}`, `{"b":[1,2],"a":1}`, nil, true},
+		{"numbers_representation", `{"a": 1.0}`, `{"a": 1}`, nil, true},
+		{
+			"nested_value",
+			`{"address": {"zip": "12345", "city": "Paris"}}`,
+			`{"address": {"city": "Paris", "zip": "12346"}}`,
+			[]Difference{{Name: "doc $.address.zip", Value1: `"12345"`, Value2: `"12346"`}},
+			true,
+		},
+		{
+			"missing_keys",
+			`{"a": 1, "odd key": true}`,
+			`{"b": null}`,
+			[]Difference{
+				{Name: "doc $.a", Value1: "1"},
+				{Name: "doc $.b", Value2: "null"},
+				{Name: `doc $["odd key"]`, Value1: "true"},
+			},
+			true,
+		},
+		{
+			"array_elements",
+			`[1, {"x": "<a>"}]`,
+			`[1, {"x": "b"}, 3]`,
+			[]Difference{
+				{Name: "doc $[1].x", Value1: `"<a>"`, Value2: `"b"`},
+				{Name: "doc $[2]", Value2: "3"},
+			},
+			true,
+		},
+		{
+			"types",
+			`{"a": [1]}`,
+			`{"a": {"0": 1}}`,
+			[]Difference{{Name: "doc $.a", Value1: "[1]", Value2: `{"0":1}`}},
+			true,
+		},
+		{"invalid", `{"a": 1}`, `{"a": `, nil, false},
+		{"trailing_data", `{"a": 1}`, `{"a": 1} {}`, nil, false},
+		{"trailing_brace", `{}`, `{}}`, nil, false},
+		{"trailing_bracket", `[1]`, `[1]]`, nil, false},
+		{"trailing_whitespace", `[1]`, "[1] \n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualDifferences, actualOk := jsonDifferences("doc", tt.value1, tt.value2)
+			require.Equal(t, tt.expectedDifferences, actualDifferences)
+			require.Equal(t, tt.expectedOk, actualOk)
+		})
+	}
+}
+
+func TestGetDifferencesJSON(t *testing.T) {
+	var (
+		fields      = []*models.Field{{Name: "doc", FieldType: "text"}, {Name: "payload", FieldType: "jsonb"}}
+		ctx         = withFieldKinds(mockV0Ctx, &models.Table{Name: "t", Fields: fields})
+		jsonCtx     = context.WithValue(mockV0Ctx, JSONColumnsContextKey, []string{"t.doc"})
+		values1     = validValues(`{"a": 1, "b": 2}`, `{"a": 1, "b": 2}`)
+		values2     = validValues(`{"b": 2, "a": 1}`, `{"b": 3, "a": 1}`)
+		differences []Difference
+		equal       = true
+	)
+
+	getDifferences(ctx, fields, values1, values2, &differences, &equal)
+	require.False(t, equal)
+	require.Equal(t, []Difference{
+		{Name: "doc", Value1: values1[0].String, Value2: values2[0].String},
+		{Name: "payload $.b", Value1: "2", Value2: "3"},
+	}, differences)
+
+	differences, equal = nil, true
+	ctx = withFieldKinds(jsonCtx, &models.Table{Name: "t", Fields: fields})
+	getDifferences(ctx, fields, values1, []sql.NullString{values2[0], values1[1]}, &differences, &equal)
+	require.True(t, equal)
+	require.Empty(t, differences)
+}
@@ -0,0 +1,218 @@
+package dbdiff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// MultiComparer is a type capable of comparing a reference entity with many target ones
+// and reporting whether any of the targets differ.
+type MultiComparer interface {
+	CompareMany(ctx context.Context, reference string, targets []string) bool
+}
+
+// NewMultiDatabaseComparer returns a new object comparing a reference database with many target ones.
+func NewMultiDatabaseComparer() MultiComparer {
+	return &databaseComparer{}
+}
+
+// DefaultParallelTargets is a default number of target databases compared concurrently.
+const DefaultParallelTargets = 4
+
+// Kinds of tables differences from the reference database output in the targets summary.
+const (
+	driftNone    = "."
+	driftMissing = "missing"
+	driftExtra   = "extra"
+	driftSchema  = "schema"
+	driftData    = "data"
+	driftError   = "error"
+)
+
+// CompareMany compares a reference database with each of target databases and reports whether any of the targets
+// differ. The reference database is read once, into an in-memory snapshot, unless rows filters are set, as they may
+// not be applicable to the snapshot. Targets are compared concurrently. Each target comparison results are output
+// in the targets order, followed by a summary of tables differing from the reference in each of the targets.
+// A target which cannot be read is reported as an error, without stopping comparison of the other ones.
+func (dc *databaseComparer) CompareMany(ctx context.Context, reference string, targets []string) bool {
+	return dc.compareMany(ctx, reference, targets, os.Stdout)
+}
+
+// compareMany compares a reference database with each of target databases, outputting results to a given writer,
+// and reports whether any of the targets differ.
+func (dc *databaseComparer) compareMany(ctx context.Context, reference string, targets []string, out io.Writer) bool {
+	parallel := fromContext(ctx, ParallelTargetsContextKey, DefaultParallelTargets)
+	if parallel < 1 {
+		log.Fatalln("invalid number of targets compared concurrently:", parallel)
+	}
+	if len(fromContext(ctx, EmitSchemaSQLContextKey, "")) > 0 || len(fromContext(ctx, EmitDataSQLContextKey, "")) > 0 {
+		log.Fatalln("statements cannot be generated for multiple targets")
+	}
+	if fromContext(ctx, UpdateBaselineContextKey, false) {
+		log.Fatalln("baseline cannot be updated for multiple targets")
+	}
+
+	// targets are parsed before the reference is read, so that invalid ones are reported early
+	databases := make([]models.Database, len(targets))
+	for i, target := range targets {
+		dc.parse(target, &databases[i])
+	}
+	var d1 models.Database
+	dc.parseReference(ctx, reference, &d1)
+	if ctx.Err() != nil {
+		return false
+	}
+
+	ctx = context.WithValue(ctx, recordChangesContextKey, true)
+	outputs := make([]bytes.Buffer, len(targets))
+	printers := make([]*resultsPrinter, len(targets))
+	errs := make([]error, len(targets))
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	wg.Add(parallel)
+	for i := 0; i < parallel; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				printers[j], _, errs[j] = dc.compareDatabases(ctx, d1, databases[j], &outputs[j])
+				if errs[j] != nil {
+					fmt.Fprintln(&outputs[j], errs[j])
+				}
+			}
+		}()
+	}
+	for j := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, target := range targets {
+		if outputs[i].Len() > 0 {
+			fmt.Fprintf(out, "Target %s:\n%s", displayName(target), outputs[i].String())
+		}
+	}
+	return writeTargetsSummary(out, targets, printers, errs)
+}
+
+// parseReference gets the reference database type and URI from a given input string. Unless the reference database
+// is a snapshot or rows filters are set, it is read into an in-memory snapshot holding full data.
+func (dc *databaseComparer) parseReference(ctx context.Context, input string, database *models.Database) {
+	_, rest := cutAlias(input)
+	if strings.HasPrefix(rest, snapshotPrefix) || len(fromContext(ctx, WhereContextKey, []string(nil))) > 0 {
+		dc.parse(input, database)
+		return
+	}
+	var file snapshotFile
+	dc.snapshot(ctx, rest, false, &file)
+	if ctx.Err() != nil {
+		return
+	}
+	dbType, uri, err := loadSnapshotFile(ctx, &file)
+	if err != nil {
+		log.Fatalln("cannot load reference database:", redactError(err))
+	}
+	database.DBType = dbType
+	database.URI = uri
+	database.Label = displayName(input)
+}
+
+// writeTargetsSummary writes a matrix of compared targets by tables differing from the reference in any of them,
+// with a kind of each table difference, and reports whether any of the targets differ. Targets which comparison
+// failed with given errors differ, with all their tables of error kind. Targets which were not compared,
+// e.g. on interrupt, are skipped.
+func writeTargetsSummary(w io.Writer, targets []string, printers []*resultsPrinter, errs []error) bool {
+	drifts := make([]map[string]string, len(targets))
+	tablesSet := make(map[string]struct{})
+	compared, differing := 0, 0
+	for i, p := range printers {
+		if errs[i] != nil {
+			compared++
+			differing++
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		compared++
+		drifts[i] = tablesDrifts(p)
+		if len(drifts[i]) > 0 {
+			differing++
+		}
+		for table := range drifts[i] {
+			tablesSet[table] = struct{}{}
+		}
+	}
+	if differing == 0 {
+		fmt.Fprintf(w, "Summary: %d of %d targets are equal to the reference\n", compared, len(targets))
+		return false
+	}
+
+	tables := make([]string, 0, len(tablesSet))
+	for table := range tablesSet {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	fmt.Fprintf(w, "Summary: %d of %d targets differ from the reference\n", differing, len(targets))
+	tw := tabwriter.NewWriter(w, 5, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "    Target\t%s\n", strings.Join(tables, "\t"))
+	for i, target := range targets {
+		if drifts[i] == nil && errs[i] == nil {
+			continue
+		}
+		cells := make([]string, len(tables))
+		for j, table := range tables {
+			cells[j] = driftNone
+			if kind, ok := drifts[i][table]; ok {
+				cells[j] = kind
+			}
+			if errs[i] != nil {
+				cells[j] = driftError
+			}
+		}
+		if errs[i] != nil && len(cells) == 0 {
+			cells = []string{driftError}
+		}
+		fmt.Fprintf(tw, "    %s\t%s\n", displayName(target), strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+	return true
+}
+
+// tablesDrifts returns kinds of differences by name of tables which differ from the reference in given comparison
+// results. Tables which results hold differences other than recorded ones, e.g. errors, are of error kind.
+func tablesDrifts(p *resultsPrinter) map[string]string {
+	drifts := make(map[string]string)
+	for _, c := range p.changes {
+		switch {
+		case len(c.name) == 0 && len(c.key) == 0 && c.base == presence(true):
+			drifts[c.table] = driftMissing
+		case len(c.name) == 0 && len(c.key) == 0:
+			drifts[c.table] = driftExtra
+		case len(c.key) == 0:
+			drifts[c.table] = driftSchema
+		default:
+			drifts[c.table] = driftData
+		}
+	}
+	for table := range p.differing {
+		if _, ok := drifts[table]; !ok {
+			drifts[table] = driftError
+		}
+	}
+	return drifts
+}
@@ -0,0 +1,97 @@
+package dbdiff
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTablesDrifts(t *testing.T) {
+	p := newResultsPrinter(&bytes.Buffer{}, false)
+	p.changes = []change{
+		{table: "a", base: "exists", value: "absent"},
+		{table: "b", base: "absent", value: "exists"},
+		{table: "c", name: "note", value: "text"},
+		{table: "d", name: "name", key: "1", base: "x", value: "y"},
+	}
+	p.differing = map[string]bool{"a": true, "b": true, "c": true, "d": true, "e": true}
+
+	require.Equal(t, map[string]string{
+		"a": driftMissing, "b": driftExtra, "c": driftSchema, "d": driftData, "e": driftError,
+	}, tablesDrifts(p))
+}
+
+func TestCompareManySqlite(t *testing.T) {
+	statements := `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, created TIMESTAMP, pic BLOB);
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, total REAL);
+		INSERT INTO users VALUES (1, 'a', '2024-01-02 03:04:05', X'0102'), (2, NULL, NULL, NULL);
+		INSERT INTO orders VALUES (1, 9.5);`
+	reference := createSqliteDatabase(t, "reference.db", statements)
+	targets := []string{
+		createSqliteDatabase(t, "t1.db", statements),
+		createSqliteDatabase(t, "t2.db", statements+"UPDATE users SET name = 'b' WHERE id = 2; DROP TABLE orders;"),
+		createSqliteDatabase(t, "t3.db", statements+"ALTER TABLE orders ADD COLUMN note TEXT;"),
+	}
+
+	var out bytes.Buffer
+	require.False(t, (&databaseComparer{}).compareMany(context.Background(), reference, targets[:1], &out))
+	require.Equal(t, "Summary: 1 of 1 targets are equal to the reference\n", out.String())
+
+	// the reference is read once for all the targets
+	out.Reset()
+	loaded := snapshotsLoaded.Load()
+	require.True(t, (&databaseComparer{}).compareMany(context.Background(), reference, targets, &out))
+	require.Equal(t, loaded+1, snapshotsLoaded.Load())
+	require.Contains(t, out.String(), "Target "+targets[1]+":\nTable orders: does not exist in "+targets[1]+"\n")
+	summary := out.String()[strings.Index(out.String(), "Summary:"):]
+	lines := strings.Split(strings.TrimSpace(summary), "\n")
+	require.Equal(t, "Summary: 2 of 3 targets differ from the reference", lines[0])
+	require.Equal(t, [][]string{
+		{"Target", "orders", "users"},
+		{targets[0], ".", "."},
+		{targets[1], "missing", "data"},
+		{targets[2], "schema", "."},
+	}, [][]string{strings.Fields(lines[1]), strings.Fields(lines[2]), strings.Fields(lines[3]), strings.Fields(lines[4])})
+}
+
+func TestCompareManyTargetErrorSqlite(t *testing.T) {
+	statements := `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users VALUES (1, 'a');`
+	reference := createSqliteDatabase(t, "reference.db", statements)
+	broken := filepath.Join(t.TempDir(), "broken.db")
+	require.NoError(t, os.WriteFile(broken, []byte("not a database file, but long enough to have a header"), 0o600))
+	targets := []string{
+		sqlite.Name() + ":" + broken,
+		createSqliteDatabase(t, "t1.db", statements),
+		createSqliteDatabase(t, "t2.db", statements+"UPDATE users SET name = 'b';"),
+	}
+
+	// a target which cannot be read does not stop comparison of the other ones
+	var out bytes.Buffer
+	require.True(t, (&databaseComparer{}).compareMany(context.Background(), reference, targets, &out))
+	require.Contains(t, out.String(), "\ncannot get "+targets[0]+" schema: file is not a database")
+	summary := out.String()[strings.Index(out.String(), "Summary:"):]
+	lines := strings.Split(strings.TrimSpace(summary), "\n")
+	require.Equal(t, "Summary: 2 of 3 targets differ from the reference", lines[0])
+	require.Equal(t, [][]string{
+		{"Target", "users"},
+		{targets[0], "error"},
+		{targets[1], "."},
+		{targets[2], "data"},
+	}, [][]string{strings.Fields(lines[1]), strings.Fields(lines[2]), strings.Fields(lines[3]), strings.Fields(lines[4])})
+
+	// a summary of a single target which cannot be read
+	out.Reset()
+	require.True(t, (&databaseComparer{}).compareMany(context.Background(), reference, targets[:1], &out))
+	summary = out.String()[strings.Index(out.String(), "Summary:"):]
+	lines = strings.Split(strings.TrimSpace(summary), "\n")
+	require.Equal(t, "Summary: 1 of 1 targets differ from the reference", lines[0])
+	require.Equal(t, []string{targets[0], "error"}, strings.Fields(lines[2]))
+}
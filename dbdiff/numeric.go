@@ -0,0 +1,57 @@
+package dbdiff
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// numericTolerance holds maximum absolute and relative differences between numeric values considered equal.
+type numericTolerance struct {
+	absolute, relative float64
+}
+
+// numericToleranceFromContext returns numeric values comparison tolerance set in context.
+func numericToleranceFromContext(ctx context.Context) numericTolerance {
+	return numericTolerance{
+		absolute: math.Abs(fromContext(ctx, AbsToleranceContextKey, 0.0)),
+		relative: math.Abs(fromContext(ctx, RelToleranceContextKey, 0.0)),
+	}
+}
+
+// numericValuesEqual reports whether two given numeric values are equal within tolerance.
+// Floating point values are compared as float64 ones, so that e.g. 1.1 and 1.1000000000000001 are equal.
+// Integer and decimal values are compared exactly, regardless of their scale, so that e.g. 10 and 10.00 are equal.
+// Values which cannot be parsed as numbers are compared as strings.
+func numericValuesEqual(kind valueKind, s1, s2 string, tolerance numericTolerance) bool {
+	if kind == floatKind {
+		f1, err1 := strconv.ParseFloat(strings.TrimSpace(s1), 64)
+		f2, err2 := strconv.ParseFloat(strings.TrimSpace(s2), 64)
+		if err1 != nil || err2 != nil {
+			return s1 == s2
+		}
+		return f1 == f2 || tolerance.accepts(math.Abs(f1-f2), math.Max(math.Abs(f1), math.Abs(f2)))
+	}
+
+	r1, ok1 := new(big.Rat).SetString(strings.TrimSpace(s1))
+	r2, ok2 := new(big.Rat).SetString(strings.TrimSpace(s2))
+	if !ok1 || !ok2 {
+		return s1 == s2
+	}
+	if r1.Cmp(r2) == 0 {
+		return true
+	}
+	difference, _ := new(big.Rat).Abs(new(big.Rat).Sub(r1, r2)).Float64()
+	magnitude, _ := new(big.Rat).Abs(r1).Float64()
+	if m2, _ := new(big.Rat).Abs(r2).Float64(); m2 > magnitude {
+		magnitude = m2
+	}
+	return tolerance.accepts(difference, magnitude)
+}
+
+// accepts reports whether an absolute difference of two values with given magnitude is within tolerance.
+func (nt numericTolerance) accepts(difference, magnitude float64) bool {
+	return difference <= nt.absolute || difference <= nt.relative*magnitude
+}
@@ -0,0 +1,53 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+func TestValuesEqualNumeric(t *testing.T) {
+	var (
+		ctxAbs = context.WithValue(context.Background(), AbsToleranceContextKey, 0.01)
+		ctxRel = context.WithValue(context.Background(), RelToleranceContextKey, 0.001)
+	)
+
+	var tests = []struct {
+		name      string
+		ctx       context.Context
+		fieldType string
+		value1    string
+		value2    string
+		expected  bool
+	}{
+		{"float_representation", mockV0Ctx, "real", "1.1", "1.1000000000000001", true},
+		{"float_different", mockV0Ctx, "double", "1.1", "1.2", false},
+		{"float_exponent", mockV0Ctx, "float8", "1e3", "1000", true},
+		{"decimal_scale", mockV0Ctx, "numeric", "10", "10.00", true},
+		{"decimal_scale_with_precision", mockV0Ctx, "numeric(10,2)", "10.5", "10.50", true},
+		{"decimal_different", mockV0Ctx, "decimal", "10.001", "10.00", false},
+		{"integer_sign", mockV0Ctx, "integer", "+5", "5", true},
+		{"text_scale", mockV0Ctx, "text", "10", "10.00", false},
+		{"not_a_number", mockV0Ctx, "numeric", "NaN", "nan", false},
+		{"absolute_tolerance", ctxAbs, "double", "1.005", "1.0", true},
+		{"absolute_tolerance_exceeded", ctxAbs, "numeric", "1.02", "1.0", false},
+		{"relative_tolerance", ctxRel, "real", "1000.5", "1000", true},
+		{"relative_tolerance_exceeded", ctxRel, "numeric", "1002", "1000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal, _ := compareValues(
+				tt.ctx,
+				&models.Field{Name: "f", FieldType: tt.fieldType},
+				sql.NullString{String: tt.value1, Valid: true},
+				sql.NullString{String: tt.value2, Valid: true},
+			)
+			require.Equal(t, tt.expected, equal)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package dbdiff
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/ygrebnov/dbdiff/models"
@@ -24,24 +26,40 @@ func (*postgresqlDatabase) Driver() string {
 	return "postgres"
 }
 
+// Parse parses PostgreSQL table schema. Column names case is kept, as names are quoted in queries.
+// NOT NULL constraints are not kept in fields attributes, so that they are not compared.
 func (*postgresqlDatabase) Parse(table *models.Table) {
+	trim := func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }
 	for _, field := range strings.Split(table.Schema, ",") {
-		field = strings.TrimFunc(
-			strings.ToLower(field),
-			func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) },
-		)
+		field = strings.TrimFunc(field, trim)
+		notNull := strings.HasSuffix(field, postgresqlNotNull)
+		field = strings.TrimFunc(strings.TrimSuffix(field, postgresqlNotNull), trim)
 		if len(field) > 0 {
 			table.AddField(field)
+			if notNull {
+				table.Fields[len(table.Fields)-1].NotNull = true
+			}
 		}
 	}
 }
 
-func (*postgresqlDatabase) QueryAll() string {
-	return `SELECT 
+func (*postgresqlDatabase) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name)
+}
+
+func (*postgresqlDatabase) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (db *postgresqlDatabase) QueryAll(filter *models.TableFilter) (string, []any) {
+	args := newQueryArgs(db)
+	return fmt.Sprintf(`SELECT 
     table_name AS name, 
-    string_agg(column_name || ' ' || data_type || ' ' || COALESCE(constraint_type, ''), ', ' order by column_name) AS sql
+    string_agg(
+		column_name || ' ' || data_type || ' ' || COALESCE(constraint_type, '') || %s, ', ' order by column_name
+	) AS sql
 FROM (
-	SELECT s.table_name, s.column_name, s.data_type, p.constraint_type
+	SELECT s.table_name, s.column_name, %s AS data_type, p.constraint_type, s.is_nullable
 	FROM information_schema.columns s
 	LEFT JOIN (
 		SELECT c.table_name, c.column_name, tc.constraint_type
@@ -52,18 +70,18 @@ FROM (
 		WHERE tc.constraint_type = 'PRIMARY KEY' and c.table_schema='public'
 	) p
 	ON s.table_name = p.table_name AND s.column_name = p.column_name
-	WHERE table_schema = 'public') comb
-GROUP BY table_name;`
+	WHERE table_schema = 'public'%s) comb
+GROUP BY table_name;`, postgresqlNotNullSQL, postgresqlDataTypeSQL, tableFilterSQL("s.table_name", filter, postgresqlGlobCondition, args)), args.values
 }
 
-func (*postgresqlDatabase) QueryOne(name string) string {
+func (*postgresqlDatabase) QueryOne(name string) (string, []any) {
 	return fmt.Sprintf(`SELECT 
 	    STRING_AGG(
-			column_name || ' ' || data_type || ' ' || COALESCE(constraint_type, ''), 
+			column_name || ' ' || data_type || ' ' || COALESCE(constraint_type, '') || %s,
 			', ' order by column_name
 		) AS sql
 	FROM (
-		SELECT s.column_name, s.data_type, p.constraint_type
+		SELECT s.column_name, %s AS data_type, p.constraint_type, s.is_nullable
 		FROM information_schema.columns s
 		LEFT JOIN (
 			SELECT c.column_name, tc.constraint_type
@@ -71,20 +89,132 @@ func (*postgresqlDatabase) QueryOne(name string) string {
 			JOIN information_schema.constraint_column_usage AS ccu USING (constraint_schema, constraint_name) 
 			JOIN information_schema.columns AS c ON c.table_schema = tc.constraint_schema
 			AND tc.table_name = c.table_name AND ccu.column_name = c.column_name
-			WHERE tc.constraint_type = 'PRIMARY KEY' and c.table_schema='public' and c.table_name='%s'
+			WHERE tc.constraint_type = 'PRIMARY KEY' and c.table_schema='public' and c.table_name=$1
 		) p
 		ON s.column_name = p.column_name
-		WHERE table_schema = 'public' AND table_name = '%s') comb;`, name, name)
+		WHERE table_schema = 'public' AND table_name = $1) comb;`, postgresqlNotNullSQL, postgresqlDataTypeSQL), []any{name}
 }
 
-func (*postgresqlDatabase) QueryExcluded(names []string) string {
+func (db *postgresqlDatabase) QueryExcluded(names []string, filter *models.TableFilter) (string, []any) {
 	var suffix string
+	args := newQueryArgs(db)
 	if len(names) > 0 {
-		suffix = fmt.Sprintf(" AND table_name NOT IN (%s)", strings.Join(names, ","))
+		placeholders := make([]string, 0, len(names))
+		for _, name := range names {
+			placeholders = append(placeholders, args.bind(name))
+		}
+		suffix = fmt.Sprintf(" AND table_name NOT IN (%s)", strings.Join(placeholders, ","))
 	}
+	suffix += tableFilterSQL("table_name", filter, postgresqlGlobCondition, args)
 	return fmt.Sprintf(`SELECT DISTINCT(table_name) AS name 
 	FROM information_schema.columns 
-	WHERE table_schema = 'public'%s;`, suffix)
+	WHERE table_schema = 'public'%s;`, suffix), args.values
+}
+
+// QuerySize returns a query selecting given table rows number estimate from planner statistics,
+// so that large tables are not scanned. Tables which have never been analyzed are estimated as empty.
+func (*postgresqlDatabase) QuerySize(name string) (string, []any) {
+	return `SELECT GREATEST(COALESCE(MAX(c.reltuples), 0), 0)::bigint
+	FROM pg_class c
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	WHERE n.nspname = 'public' AND c.relname = $1;`, []any{name}
+}
+
+// QueryIndexes returns a query selecting PostgreSQL table indexes. Indexes backing UNIQUE constraints are flagged
+// as constraints. Expressions indexes columns are not selected.
+func (*postgresqlDatabase) QueryIndexes(name string) (string, []any) {
+	return `SELECT i.relname, ix.indisunique, c.conname IS NOT NULL, string_agg(a.attname, ',' ORDER BY k.ord)
+	FROM pg_index ix
+	JOIN pg_class t ON t.oid = ix.indrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	LEFT JOIN pg_constraint c ON c.conindid = ix.indexrelid AND c.contype = 'u'
+	CROSS JOIN LATERAL unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+	JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+	WHERE n.nspname = 'public' AND t.relname = $1 AND NOT ix.indisprimary
+	GROUP BY i.relname, ix.indisunique, c.conname
+	ORDER BY i.relname;`, []any{name}
+}
+
+// QueryReferences returns a query selecting names of tables referenced by PostgreSQL table foreign keys.
+func (*postgresqlDatabase) QueryReferences(name string) (string, []any) {
+	return `SELECT DISTINCT r.relname
+	FROM pg_constraint c
+	JOIN pg_class t ON t.oid = c.conrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	JOIN pg_class r ON r.oid = c.confrelid
+	WHERE c.contype = 'f' AND n.nspname = 'public' AND t.relname = $1
+	ORDER BY 1;`, []any{name}
+}
+
+// ColumnType returns PostgreSQL field full type. Schemas hold types as multiple words, e.g. "character varying",
+// the first one being the field type and the others being kept in field attributes along with constraints.
+// Arrays types are returned as their elements types followed by [], e.g. int4[].
+func (*postgresqlDatabase) ColumnType(field *models.Field) string {
+	attrs := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(field.Attrs), "primary key"))
+	if field.FieldType == "array" && len(attrs) > 0 {
+		return attrs + "[]"
+	}
+	return strings.TrimSpace(field.FieldType + " " + attrs)
+}
+
+// Canonicalizers returns PostgreSQL values canonicalizers. Only boolean values, returned by the driver as true or false,
+// are canonicalized, so that they are equal to boolean values of other databases, e.g. SQLite 0 and 1.
+func (*postgresqlDatabase) Canonicalizers() models.Canonicalizers {
+	return postgresqlCanonicalizers
+}
+
+// TxOptions returns PostgreSQL read-only REPEATABLE READ transactions options, so that all the transaction reads
+// observe the snapshot of data taken on its first query.
+func (*postgresqlDatabase) TxOptions() *sql.TxOptions {
+	return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+}
+
+func (*postgresqlDatabase) QueryExportSnapshot() string {
+	return "SELECT pg_export_snapshot();"
+}
+
+func (*postgresqlDatabase) QueryImportSnapshot(id string) string {
+	// utility statements do not accept parameters
+	return fmt.Sprintf("SET TRANSACTION SNAPSHOT %s;", quoteLiteral(id))
+}
+
+// QueryStatementTimeout returns a statement setting PostgreSQL statement_timeout for the rest of the transaction,
+// so that a timed out statement is canceled by the server.
+func (*postgresqlDatabase) QueryStatementTimeout(timeout time.Duration) string {
+	ms := timeout.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return fmt.Sprintf("SET LOCAL statement_timeout = %d;", ms)
+}
+
+// postgresqlNotNull suffixes PostgreSQL schemas columns declared NOT NULL.
+const postgresqlNotNull = " NOT NULL"
+
+// postgresqlNotNullSQL is an expression returning a suffix of a column declared NOT NULL in PostgreSQL schema.
+const postgresqlNotNullSQL = `CASE WHEN is_nullable = 'NO' THEN '` + postgresqlNotNull + `' ELSE '' END`
+
+// postgresqlDataTypeSQL selects columns data types. Arrays data types are followed by their elements types,
+// e.g. "ARRAY int4", so that elements can be compared according to their type.
+const postgresqlDataTypeSQL = `CASE WHEN s.data_type = 'ARRAY' THEN 'ARRAY ' || substr(s.udt_name, 2) ELSE s.data_type END`
+
+var postgresqlCanonicalizers = models.Canonicalizers{
+	"boolean": canonicalizeBoolean,
+}
+
+// postgresqlGlobCondition translates a glob pattern into a PostgreSQL LIKE condition.
+// Patterns containing character classes or escapes cannot be expressed with LIKE.
+func postgresqlGlobCondition(column, pattern string, negate bool, args *queryArgs) (string, bool) {
+	if strings.ContainsAny(pattern, `[\`) {
+		return "", false
+	}
+	like := strings.NewReplacer("%", `\%`, "_", `\_`, "*", "%", "?", "_").Replace(strings.ToLower(pattern))
+	operator := "LIKE"
+	if negate {
+		operator = "NOT LIKE"
+	}
+	return fmt.Sprintf(`lower(%s) %s %s ESCAPE '\'`, column, operator, args.bind(like)), true
 }
 
 var postgresql = newPostgresqlDatabase()
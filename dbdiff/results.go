@@ -0,0 +1,201 @@
+package dbdiff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// schemaSQLHeader is a header of a script turning database2 schema into database1 one.
+const schemaSQLHeader = "-- Statements turning database2 schema into database1 one.\n" +
+	"-- Statements which may lose data are commented out, unless requested otherwise."
+
+// tableResult is a table comparison result.
+type tableResult struct {
+	// table is a compared table name.
+	table string
+	// text is a result representation, empty if there is nothing to output.
+	text string
+	// final indicates that no more results are expected for the table.
+	final bool
+	// statements holds statements turning database2 table schema into database1 one, if requested.
+	statements []string
+	// patch holds statements turning database2 table data into database1 one, if requested.
+	patch *dataPatch
+	// equal indicates that the text, output at a verbosity level above the default one, holds no differences.
+	equal bool
+	// suppressed is a number of differences accepted by the baseline.
+	suppressed int
+	// baseline holds the table differences recorded for baseline update.
+	baseline []baselineEntry
+	// changes holds the table differences values, if recorded.
+	changes []change
+}
+
+// newTableResult returns a table comparison result which is not final.
+func newTableResult(table, text string) tableResult {
+	return tableResult{table: table, text: text}
+}
+
+// newFinalTableResult returns a table comparison final result.
+func newFinalTableResult(table, text string) tableResult {
+	return tableResult{table: table, text: text, final: true}
+}
+
+// resultsPrinter outputs tables comparison results ordered by table name. Results of each table are output
+// in the order they are obtained, so that schema comparison results precede data comparison ones.
+// Results are either collected and output on comparison completion, or streamed, i.e. each table results are output
+// as soon as all the tables are known and results of the table and all the preceding ones are final.
+type resultsPrinter struct {
+	out    io.Writer
+	stream bool
+	// results holds not yet output results texts by table name.
+	results map[string][]string
+	// final holds names of tables which results are final.
+	final map[string]bool
+	// tables holds sorted names of all compared tables, once they are known.
+	tables []string
+	// next is an index of the next table in tables slice to output results of.
+	next int
+	// statements holds schema migration statements by table name.
+	statements map[string][]string
+	// patches holds data patches by table name.
+	patches map[string]*dataPatch
+	// differences indicates whether any results texts holding differences were added.
+	differences bool
+	// differing holds names of tables which results texts hold differences.
+	differing map[string]bool
+	// suppressed is a number of differences accepted by the baseline.
+	suppressed int
+	// baseline holds differences recorded for baseline update.
+	baseline []baselineEntry
+	// changes holds differences values, if recorded.
+	changes []change
+}
+
+// newResultsPrinter returns a new resultsPrinter object.
+func newResultsPrinter(out io.Writer, stream bool) *resultsPrinter {
+	return &resultsPrinter{
+		out:        out,
+		stream:     stream,
+		results:    make(map[string][]string),
+		final:      make(map[string]bool),
+		differing:  make(map[string]bool),
+		statements: make(map[string][]string),
+		patches:    make(map[string]*dataPatch),
+	}
+}
+
+// add adds a table comparison result.
+func (p *resultsPrinter) add(r tableResult) {
+	if _, ok := p.results[r.table]; !ok {
+		p.results[r.table] = nil
+	}
+	if len(r.text) > 0 {
+		p.results[r.table] = append(p.results[r.table], r.text)
+		if !r.equal {
+			p.differences = true
+			p.differing[r.table] = true
+		}
+	}
+	p.suppressed += r.suppressed
+	p.baseline = append(p.baseline, r.baseline...)
+	p.changes = append(p.changes, r.changes...)
+	if r.final {
+		p.final[r.table] = true
+	}
+	if len(r.statements) > 0 {
+		p.statements[r.table] = append(p.statements[r.table], r.statements...)
+	}
+	if r.patch != nil {
+		p.patches[r.table] = r.patch
+	}
+	if p.stream && p.tables != nil {
+		p.flush(false)
+	}
+}
+
+// seal marks all the compared tables as known, i.e. no results of other tables are expected.
+// Each compared table must have at least one result added before sealing.
+func (p *resultsPrinter) seal() {
+	p.tables = make([]string, 0, len(p.results))
+	for table := range p.results {
+		p.tables = append(p.tables, table)
+	}
+	sort.Strings(p.tables)
+	if p.stream {
+		p.flush(false)
+	}
+}
+
+// close outputs all the results not yet output.
+func (p *resultsPrinter) close() {
+	if p.tables == nil {
+		p.seal()
+	}
+	p.flush(true)
+}
+
+// flush outputs results of tables in order. Unless all is true, output stops at the first table
+// which results are not final.
+func (p *resultsPrinter) flush(all bool) {
+	for ; p.next < len(p.tables); p.next++ {
+		table := p.tables[p.next]
+		if !all && !p.final[table] {
+			return
+		}
+		for _, text := range p.results[table] {
+			fmt.Fprintln(p.out, text)
+		}
+		delete(p.results, table)
+	}
+}
+
+// schemaTables returns names of tables having schema migration statements, sorted.
+func (p *resultsPrinter) schemaTables() []string {
+	tables := make([]string, 0, len(p.statements))
+	for table := range p.statements {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// schemaStatements returns schema migration statements ordered by table name.
+func (p *resultsPrinter) schemaStatements() []string {
+	var statements []string
+	for _, table := range p.schemaTables() {
+		statements = append(statements, p.statements[table]...)
+	}
+	return statements
+}
+
+// writeSchemaSQL writes schema migration statements ordered by table name.
+func (p *resultsPrinter) writeSchemaSQL(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, schemaSQLHeader); err != nil {
+		return err
+	}
+	for _, table := range p.schemaTables() {
+		if _, err := fmt.Fprintf(w, "\n-- Table %s\n%s\n", table, strings.Join(p.statements[table], "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dataStatements returns data patches statements ordered by foreign keys dependencies.
+func (p *resultsPrinter) dataStatements() []string {
+	var statements []string
+	for _, section := range patchSections(p.patches) {
+		statements = append(statements, section.statements...)
+	}
+	return statements
+}
+
+// writeDataSQL writes data patches statements in a given database type dialect.
+func (p *resultsPrinter) writeDataSQL(w io.Writer, target models.DatabaseType) error {
+	return writeDataPatch(w, target, p.patches)
+}
@@ -0,0 +1,43 @@
+package dbdiff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultsPrinter(t *testing.T) {
+	var tests = []struct {
+		name   string
+		stream bool
+		// expectedOutputs holds expected output after each step: adding results, sealing, adding more results, closing
+		expectedOutputs []string
+	}{
+		{"collect", false, []string{"", "", "", "a schema\na data\nb\nc\nd data\n"}},
+		{"stream", true, []string{"", "", "a schema\na data\nb\nc\n", "a schema\na data\nb\nc\nd data\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := newResultsPrinter(&out, tt.stream)
+
+			p.add(newFinalTableResult("c", "c"))
+			p.add(newTableResult("a", "a schema"))
+			p.add(newTableResult("d", ""))
+			p.add(newFinalTableResult("b", "b"))
+			require.Equal(t, tt.expectedOutputs[0], out.String())
+
+			p.seal()
+			require.Equal(t, tt.expectedOutputs[1], out.String())
+
+			p.add(newFinalTableResult("a", "a data"))
+			require.Equal(t, tt.expectedOutputs[2], out.String())
+
+			p.add(newTableResult("d", "d data"))
+			p.close()
+			require.Equal(t, tt.expectedOutputs[3], out.String())
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// snapshotDatabase defines methods applicable to a database loaded from a snapshot file into an in-memory SQLite
+// database. Tables schemas are kept as retrieved from the source database, so that they are parsed and compared
+// as the source database ones. Tables hold canonical values of all fields as text, or rows hashes only.
+// Implements [models.DatabaseType] interface.
+type snapshotDatabase struct {
+	*sqliteDatabase
+	// source is the snapshot source database type.
+	source models.DatabaseType
+	// rowHashes indicates that rows hashes are stored instead of data.
+	rowHashes bool
+	// timezone and timestampPrecision are the timestamp comparison options the snapshot was taken with.
+	timezone, timestampPrecision string
+	// timestampOptions are the parsed snapshot timestamp comparison options.
+	timestampOptions timestampOptions
+	// keeper keeps the in-memory database open.
+	keeper *sql.DB
+}
+
+// snapshotPrefix prefixes snapshot files paths in database identification strings.
+const snapshotPrefix = "snapshot:"
+
+// snapshotMetadataPrefix prefixes snapshot metadata tables names. Tables with names starting with it
+// cannot be saved into snapshots, so that metadata tables do not clash with data ones.
+const snapshotMetadataPrefix = "__dbdiff_snapshot_"
+
+const (
+	snapshotTablesTable     = snapshotMetadataPrefix + "tables"
+	snapshotIndexesTable    = snapshotMetadataPrefix + "indexes"
+	snapshotReferencesTable = snapshotMetadataPrefix + "references"
+	snapshotHashesTable     = snapshotMetadataPrefix + "row_hashes"
+)
+
+func (*snapshotDatabase) Name() string {
+	return "snapshot"
+}
+
+// Parse parses table schema as the source database type does.
+func (db *snapshotDatabase) Parse(table *models.Table) {
+	db.source.Parse(table)
+}
+
+func (db *snapshotDatabase) QueryAll(filter *models.TableFilter) (string, []any) {
+	args := newQueryArgs(db)
+	return fmt.Sprintf(
+		"SELECT name, schema FROM %s WHERE 1 = 1%s;",
+		snapshotTablesTable, tableFilterSQL("name", filter, sqliteGlobCondition, args),
+	), args.values
+}
+
+func (*snapshotDatabase) QueryOne(name string) (string, []any) {
+	return fmt.Sprintf("SELECT schema FROM %s WHERE name = ?;", snapshotTablesTable), []any{name}
+}
+
+func (db *snapshotDatabase) QueryExcluded(names []string, filter *models.TableFilter) (string, []any) {
+	args := newQueryArgs(db)
+	placeholders := make([]string, 0, len(names))
+	for _, name := range names {
+		placeholders = append(placeholders, args.bind(name))
+	}
+	return fmt.Sprintf(
+		"SELECT name FROM %s WHERE name NOT IN (%s)%s;",
+		snapshotTablesTable, strings.Join(placeholders, ","), tableFilterSQL("name", filter, sqliteGlobCondition, args),
+	), args.values
+}
+
+func (*snapshotDatabase) QuerySize(name string) (string, []any) {
+	return fmt.Sprintf("SELECT size FROM %s WHERE name = ?;", snapshotTablesTable), []any{name}
+}
+
+func (*snapshotDatabase) QueryIndexes(name string) (string, []any) {
+	return fmt.Sprintf(
+		"SELECT name, is_unique, is_constraint, columns FROM %s WHERE table_name = ? ORDER BY name;",
+		snapshotIndexesTable,
+	), []any{name}
+}
+
+func (*snapshotDatabase) QueryReferences(name string) (string, []any) {
+	return fmt.Sprintf(
+		"SELECT referenced FROM %s WHERE table_name = ? ORDER BY referenced;", snapshotReferencesTable,
+	), []any{name}
+}
+
+// queryRowHashes returns a query selecting given table primary key values and rows hashes, and its parameters.
+func (*snapshotDatabase) queryRowHashes(name string) (string, []any) {
+	return fmt.Sprintf("SELECT pk, hash FROM %s WHERE table_name = ? ORDER BY pk;", snapshotHashesTable), []any{name}
+}
+
+// ColumnType returns field full type as the source database type does.
+func (db *snapshotDatabase) ColumnType(field *models.Field) string {
+	return db.source.ColumnType(field)
+}
+
+// Canonicalizers returns no canonicalizers: snapshot values are already canonical.
+func (*snapshotDatabase) Canonicalizers() models.Canonicalizers {
+	return nil
+}
+
+// dialectOf returns a name of a given database type dialect. Snapshots have their source databases dialects.
+func dialectOf(dbType models.DatabaseType) string {
+	if s, ok := dbType.(*snapshotDatabase); ok {
+		return s.source.Name()
+	}
+	return dbType.Name()
+}
+
+// hasRowHashes reports whether a given database type is a snapshot holding rows hashes instead of data.
+func hasRowHashes(dbType models.DatabaseType) bool {
+	s, ok := dbType.(*snapshotDatabase)
+	return ok && s.rowHashes
+}
+
+// checkTimestampOptions returns an error if given timestamp comparison options differ from the ones
+// the snapshot was taken with: snapshot values are stored normalized and are not normalized again.
+func (db *snapshotDatabase) checkTimestampOptions(options timestampOptions) error {
+	if options.location.String() == db.timestampOptions.location.String() &&
+		options.precision == db.timestampOptions.precision {
+		return nil
+	}
+	return fmt.Errorf(
+		"snapshot is taken with -timezone %s -timestamp-precision %s, compare it with the same options",
+		db.timezone, db.timestampPrecision,
+	)
+}
+
+// checkSnapshotTableName returns an error if a given table name starts with the snapshot metadata tables prefix.
+// Names are compared case-insensitively, as SQLite does.
+func checkSnapshotTableName(name string) error {
+	if strings.HasPrefix(strings.ToLower(name), snapshotMetadataPrefix) {
+		return fmt.Errorf("table %s name starts with %s reserved for snapshot metadata", name, snapshotMetadataPrefix)
+	}
+	return nil
+}
+
+// loadSnapshot loads a snapshot file with a given path into a new in-memory SQLite database.
+// Returns the snapshot database type and the in-memory database URI.
+func loadSnapshot(ctx context.Context, path string) (*snapshotDatabase, string, error) {
+	file, err := readSnapshotFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return loadSnapshotFile(ctx, file)
+}
+
+// loadSnapshotFile loads a given snapshot into a new in-memory SQLite database.
+// Returns the snapshot database type and the in-memory database URI.
+func loadSnapshotFile(ctx context.Context, file *snapshotFile) (*snapshotDatabase, string, error) {
+	var err error
+	db := &snapshotDatabase{sqliteDatabase: sqlite.(*sqliteDatabase), rowHashes: file.RowHashes}
+	switch file.Source {
+	case sqlite.Name():
+		db.source = sqlite
+	case postgresql.Name():
+		db.source = postgresql
+	default:
+		return nil, "", fmt.Errorf("unknown snapshot source database type %q", file.Source)
+	}
+	db.timezone, db.timestampPrecision = file.Timezone, file.TimestampPrecision
+	options := context.WithValue(context.Background(), TimezoneContextKey, file.Timezone)
+	options = context.WithValue(options, TimestampPrecisionContextKey, file.TimestampPrecision)
+	if db.timestampOptions, err = parseTimestampOptions(options); err != nil {
+		return nil, "", fmt.Errorf("invalid snapshot timestamp options: %w", err)
+	}
+
+	// the in-memory database is shared by all connections and exists while at least one of them is open
+	uri := fmt.Sprintf("file:dbdiff_snapshot_%d?mode=memory&cache=shared", snapshotsLoaded.Add(1))
+	if db.keeper, err = sql.Open(sqlite.Driver(), uri); err != nil {
+		return nil, "", err
+	}
+	db.keeper.SetConnMaxIdleTime(0)
+	db.keeper.SetMaxIdleConns(1)
+
+	tx, err := db.keeper.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+	if err = createSnapshotTables(ctx, tx, file); err != nil {
+		return nil, "", err
+	}
+	return db, uri, tx.Commit()
+}
+
+// createSnapshotTables creates and fills snapshot metadata and data tables.
+func createSnapshotTables(ctx context.Context, tx *sql.Tx, file *snapshotFile) error {
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (name TEXT PRIMARY KEY, schema TEXT, size INTEGER);", snapshotTablesTable),
+		fmt.Sprintf(
+			"CREATE TABLE %s (table_name TEXT, name TEXT, is_unique BOOLEAN, is_constraint BOOLEAN, columns TEXT);",
+			snapshotIndexesTable,
+		),
+		fmt.Sprintf("CREATE TABLE %s (table_name TEXT, referenced TEXT);", snapshotReferencesTable),
+		fmt.Sprintf(
+			"CREATE TABLE %s (table_name TEXT, pk TEXT, hash TEXT, PRIMARY KEY (table_name, pk));",
+			snapshotHashesTable,
+		),
+	}
+	for _, s := range statements {
+		if _, err := tx.ExecContext(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range file.Tables {
+		if err := checkSnapshotTableName(t.Name); err != nil {
+			return err
+		}
+		size := len(t.Rows) + len(t.Hashes)
+		if err := execAll(ctx, tx, fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?);", snapshotTablesTable),
+			[][]any{{t.Name, t.Schema, size}}); err != nil {
+			return err
+		}
+		indexes := make([][]any, 0, len(t.Indexes))
+		for _, index := range t.Indexes {
+			indexes = append(indexes, []any{t.Name, index.Name, index.Unique, index.Constraint, strings.Join(index.Columns, ",")})
+		}
+		if err := execAll(ctx, tx, fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?, ?, ?);", snapshotIndexesTable), indexes); err != nil {
+			return err
+		}
+		references := make([][]any, 0, len(t.References))
+		for _, reference := range t.References {
+			references = append(references, []any{t.Name, reference})
+		}
+		if err := execAll(ctx, tx, fmt.Sprintf("INSERT INTO %s VALUES (?, ?);", snapshotReferencesTable), references); err != nil {
+			return err
+		}
+		hashes := make([][]any, 0, len(t.Hashes))
+		for pk, hash := range t.Hashes {
+			hashes = append(hashes, []any{t.Name, pk, hash})
+		}
+		if err := execAll(ctx, tx, fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?);", snapshotHashesTable), hashes); err != nil {
+			return err
+		}
+		if err := createSnapshotDataTable(ctx, tx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSnapshotDataTable creates a table holding a given snapshot table data as text and fills it.
+func createSnapshotDataTable(ctx context.Context, tx *sql.Tx, t snapshotTable) error {
+	columns := make([]string, 0, len(t.Columns))
+	placeholders := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		column := quoteIdentifier(c) + " TEXT"
+		if c == t.PrimaryKey {
+			column += " PRIMARY KEY"
+		}
+		columns = append(columns, column)
+		placeholders = append(placeholders, "?")
+	}
+	if _, err := tx.ExecContext(
+		ctx, fmt.Sprintf("CREATE TABLE %s (%s);", quoteIdentifier(t.Name), strings.Join(columns, ", ")),
+	); err != nil {
+		return err
+	}
+
+	rows := make([][]any, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		values := make([]any, len(row))
+		for i, v := range row {
+			if v != nil {
+				values[i] = *v
+			}
+		}
+		rows = append(rows, values)
+	}
+	return execAll(ctx, tx, fmt.Sprintf(
+		"INSERT INTO %s VALUES (%s);", quoteIdentifier(t.Name), strings.Join(placeholders, ", "),
+	), rows)
+}
+
+// execAll executes a given statement with each of given parameters sets.
+func execAll(ctx context.Context, tx *sql.Tx, query string, argsSets [][]any) error {
+	if len(argsSets) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, args := range argsSets {
+		if _, err = stmt.ExecContext(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
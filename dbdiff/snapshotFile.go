@@ -0,0 +1,526 @@
+package dbdiff
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// snapshotVersion is a version of snapshot files format.
+const snapshotVersion = 1
+
+// snapshotFile is a portable database snapshot: tables schemas as retrieved from the source database, their indexes
+// and foreign keys, along with either rows hashes or full data. Values are stored in their canonical representations,
+// binary values are stored in hex.
+type snapshotFile struct {
+	Version int `json:"version"`
+	// Source is a name of the source database type.
+	Source  string    `json:"source"`
+	Created time.Time `json:"created"`
+	// RowHashes indicates that rows hashes are stored instead of data.
+	RowHashes          bool            `json:"rowHashes"`
+	Timezone           string          `json:"timezone"`
+	TimestampPrecision string          `json:"timestampPrecision"`
+	Tables             []snapshotTable `json:"tables"`
+}
+
+// snapshotTable is a table stored in a snapshot file.
+type snapshotTable struct {
+	Name       string          `json:"name"`
+	Schema     string          `json:"schema"`
+	PrimaryKey string          `json:"primaryKey,omitempty"`
+	Columns    []string        `json:"columns"`
+	Indexes    []snapshotIndex `json:"indexes,omitempty"`
+	References []string        `json:"references,omitempty"`
+	// Rows holds rows values ordered as columns. NULL values are stored as nulls.
+	Rows [][]*string `json:"rows,omitempty"`
+	// Hashes holds rows hashes by primary key value.
+	Hashes map[string]string `json:"hashes,omitempty"`
+}
+
+// snapshotIndex is a table index stored in a snapshot file.
+type snapshotIndex struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	Unique     bool     `json:"unique,omitempty"`
+	Constraint bool     `json:"constraint,omitempty"`
+}
+
+// Snapshotter is a type capable of writing an entity snapshot to a file.
+type Snapshotter interface {
+	Snapshot(ctx context.Context, entity, path string)
+}
+
+// NewDatabaseSnapshotter returns a new object writing database snapshots.
+func NewDatabaseSnapshotter() Snapshotter {
+	return &databaseComparer{}
+}
+
+// Snapshot writes a given database snapshot to a file with a given path. The database is read in a single snapshot
+// of data. Rows hashes are written, unless full data is requested. The file is compressed with gzip if requested
+// or if the path has .gz extension. Only tables passing tables filters are written, only rows matching rows filters.
+// Tables are written one by one, so that the whole snapshot is not kept in memory.
+func (dc *databaseComparer) Snapshot(ctx context.Context, input, path string) {
+	compress := fromContext(ctx, SnapshotGzipContextKey, false) || strings.HasSuffix(path, ".gz")
+	w, err := createSnapshotWriter(path, compress)
+	if err != nil {
+		log.Fatalln("cannot write snapshot file:", err)
+	}
+	dc.snapshot(ctx, input, !fromContext(ctx, SnapshotDataContextKey, false), w)
+	if err = w.close(); err != nil {
+		log.Fatalln("cannot write snapshot file:", err)
+	}
+}
+
+// snapshotSink receives a database snapshot header, i.e. the snapshot without tables, followed by its tables.
+type snapshotSink interface {
+	begin(header *snapshotFile) error
+	add(table snapshotTable) error
+}
+
+// begin sets the snapshot header.
+func (file *snapshotFile) begin(header *snapshotFile) error {
+	*file = *header
+	return nil
+}
+
+// add appends a table to the snapshot.
+func (file *snapshotFile) add(table snapshotTable) error {
+	file.Tables = append(file.Tables, table)
+	return nil
+}
+
+// snapshot reads a given database in a single snapshot of data and sends its snapshot holding either rows hashes
+// or full data to a given sink, table by table.
+func (dc *databaseComparer) snapshot(ctx context.Context, input string, rowHashes bool, sink snapshotSink) {
+	var d models.Database
+
+	predicates, err := parseTablePredicates(fromContext(ctx, WhereContextKey, []string(nil)))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	options, err := parseTimestampOptions(ctx)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	dc.parse(input, &d)
+	if _, ok := d.DBType.(*snapshotDatabase); ok {
+		log.Fatalln("cannot take a snapshot of a snapshot")
+	}
+	if err = dc.createHandler(ctx, &d, 1); err != nil {
+		log.Fatalln(err)
+	}
+	defer d.Handler.Close()
+	d.StatementTimeout = fromContext(ctx, StatementTimeoutContextKey, time.Duration(0))
+	if err = dc.beginSnapshot(ctx, &d, "database"); err != nil {
+		log.Fatalln(err)
+	}
+	defer d.EndSnapshot()
+
+	// binary values are stored entirely, so that snapshots can be compared with any options
+	ctx = context.WithValue(ctx, timestampOptionsContextKey, options)
+	ctx = context.WithValue(ctx, BinaryHashOnlyContextKey, false)
+
+	file := snapshotFile{
+		Version:            snapshotVersion,
+		Source:             d.DBType.Name(),
+		Created:            time.Now().UTC(),
+		RowHashes:          rowHashes,
+		Timezone:           fromContext(ctx, TimezoneContextKey, "UTC"),
+		TimestampPrecision: fromContext(ctx, TimestampPrecisionContextKey, "ns"),
+	}
+	tables, err := snapshotTables(ctx, d)
+	if err != nil {
+		log.Fatalln("cannot get database schema:", err)
+	}
+	for _, t := range tables {
+		if err = checkSnapshotTableName(t.Name); err != nil {
+			log.Fatalln(err, "- exclude it with -exclude-tables option")
+		}
+	}
+	if err = sink.begin(&file); err != nil {
+		log.Fatalln("cannot write snapshot file:", err)
+	}
+	for _, t := range tables {
+		t.Predicate = predicateFor(predicates, t.Name)
+		st, err := snapshotTableOf(ctx, d, t, file.RowHashes)
+		if err != nil {
+			log.Fatalf("cannot take table %s snapshot: %v\n", t.Name, err)
+		}
+		if err = sink.add(st); err != nil {
+			log.Fatalln("cannot write snapshot file:", err)
+		}
+	}
+}
+
+// snapshotTables returns tables of a given database passing tables filters set in context.
+func snapshotTables(ctx context.Context, d models.Database) ([]*models.Table, error) {
+	filter := models.NewTableFilter(
+		fromContext(ctx, TablesContextKey, []string(nil)),
+		fromContext(ctx, ExcludeTablesContextKey, []string(nil)),
+	)
+	query, args := d.DBType.QueryAll(filter)
+	rows, cancel, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer rows.Close()
+
+	var tables []*models.Table
+	for rows.Next() {
+		t := &models.Table{DB: &d, FieldNameIndex: make(map[string]int)}
+		if err = t.GetFieldsFromRow(rows); err != nil {
+			return nil, err
+		}
+		// filters are not always fully applied by database queries
+		if filter.Match(t.Name) {
+			tables = append(tables, t)
+		}
+	}
+	return tables, rows.Err()
+}
+
+// snapshotTableOf returns a snapshot of a given table with either rows hashes or full data.
+// Data of tables without primary keys is not stored, as it cannot be compared.
+func snapshotTableOf(ctx context.Context, d models.Database, t *models.Table, rowHashes bool) (snapshotTable, error) {
+	st := snapshotTable{Name: t.Name, Schema: t.Schema}
+	if err := t.GetIndexes(ctx); err != nil {
+		return st, err
+	}
+	for _, index := range t.Indexes {
+		st.Indexes = append(st.Indexes, snapshotIndex(*index))
+	}
+	if err := t.GetReferences(ctx); err != nil {
+		return st, err
+	}
+	st.References = t.References
+
+	query := t.QueryDataAll(d.DBType) // sorts table fields
+	for _, f := range t.Fields {
+		st.Columns = append(st.Columns, f.Name)
+	}
+	if t.PrimaryKey == nil {
+		return st, nil
+	}
+	st.PrimaryKey = t.PrimaryKey.Name
+
+	ctx = withFieldKinds(ctx, t)
+	if rowHashes {
+		hashes, _, err := tableRowHashes(ctx, d, t)
+		st.Hashes = hashes
+		return st, err
+	}
+
+	rows, cancel, err := d.QueryContext(ctx, query)
+	if err != nil {
+		return st, err
+	}
+	defer cancel()
+	defer rows.Close()
+	convert := newValueConverter(ctx, d.DBType)
+	for rows.Next() {
+		values, err := scanTableRow(ctx, rows, t, convert)
+		if err != nil {
+			return st, err
+		}
+		row := make([]*string, len(values))
+		for i, v := range values {
+			if !v.Valid {
+				continue
+			}
+			value := v.String
+			if kindOf(ctx, t.Fields[i]) == binaryKind {
+				value = `\x` + hex.EncodeToString([]byte(value))
+			}
+			row[i] = &value
+		}
+		st.Rows = append(st.Rows, row)
+	}
+	return st, rows.Err()
+}
+
+// scanTableRow scans a row retrieved with table data query and returns canonical values of the row fields.
+// The leading primary key value is skipped.
+func scanTableRow(ctx context.Context, rows *sql.Rows, t *models.Table, convert models.ValueConverter) ([]sql.NullString, error) {
+	raw := make([]any, len(t.Fields)+1)
+	for i := range raw {
+		raw[i] = new(sql.NullString)
+	}
+	if err := rows.Scan(scanDestinations(ctx, t.Fields, raw)...); err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(t.Fields))
+	fieldsRaw := raw[1:]
+	t.ParseRawSQLValues(&fieldsRaw, &values, convert)
+	return values, nil
+}
+
+// tableRowHashes returns hashes of a given table rows by primary key value, along with primary key values
+// in the order rows are retrieved. Hashes are read from row hashes snapshots, or computed from rows canonical values.
+func tableRowHashes(ctx context.Context, d models.Database, t *models.Table) (map[string]string, []string, error) {
+	hashes := make(map[string]string)
+	var pks []string
+
+	query, args := t.QueryDataAll(d.DBType), []any(nil)
+	if s, ok := d.DBType.(*snapshotDatabase); ok && s.rowHashes {
+		query, args = s.queryRowHashes(t.Name)
+	}
+	rows, cancel, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cancel()
+	defer rows.Close()
+
+	convert := newValueConverter(ctx, d.DBType)
+	for rows.Next() {
+		var pk, hash sql.NullString
+		if s, ok := d.DBType.(*snapshotDatabase); ok && s.rowHashes {
+			if err = rows.Scan(&pk, &hash); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			raw := make([]any, len(t.Fields)+1)
+			raw[0] = &pk
+			for i := 1; i < len(raw); i++ {
+				raw[i] = new(sql.NullString)
+			}
+			if err = rows.Scan(scanDestinations(ctx, t.Fields, raw)...); err != nil {
+				return nil, nil, err
+			}
+			values := make([]sql.NullString, len(t.Fields))
+			fieldsRaw := raw[1:]
+			t.ParseRawSQLValues(&fieldsRaw, &values, convert)
+			hash.String = rowHash(ctx, t.Fields, values)
+		}
+		hashes[pk.String] = hash.String
+		pks = append(pks, pk.String)
+	}
+	return hashes, pks, rows.Err()
+}
+
+// compareTablesRowHashes compares a given table data in two databases, at least one of which is a snapshot holding
+// rows hashes, by rows hashes. Rows are matched by primary key values. Rows filters and ignored columns
+// cannot be applied, as rows hashes cover all the rows and columns.
+func compareTablesRowHashes(ctx context.Context, d1, d2 models.Database, t *models.Table, results chan tableResult) {
+	var (
+		end1, end2 func()
+		err        error
+	)
+	verbose := fromContext(ctx, VerboseContextKey, false) || fromContext(ctx, VVerboseContextKey, false)
+	vvverbose := fromContext(ctx, VVVerboseContextKey, false)
+	result := fmt.Sprintf("Table %s data differences:", t.Name)
+	if verbose || vvverbose {
+		result = t.ComparisonResult + "\n  data differences:"
+	}
+
+	if len(predicateFor(fromContext(ctx, predicatesContextKey, []tablePredicate(nil)), t.Name)) > 0 ||
+		len(t.IgnoredFields) > 0 {
+		results <- newFinalTableResult(t.Name, fmt.Sprintf(
+			"%s\n  rows filters and ignored columns cannot be applied to rows hashes", result,
+		))
+		return
+	}
+
+	if d1, end1, err = d1.JoinSnapshot(ctx); err != nil {
+		results <- newFinalTableResult(t.Name, fmt.Sprintf("%s: cannot join %s snapshot: %q", t.ID(), contextLabel(ctx, 1, false), redactError(err)))
+		return
+	}
+	defer end1()
+	if d2, end2, err = d2.JoinSnapshot(ctx); err != nil {
+		results <- newFinalTableResult(t.Name, fmt.Sprintf("%s: cannot join %s snapshot: %q", t.ID(), contextLabel(ctx, 2, false), redactError(err)))
+		return
+	}
+	defer end2()
+
+	ctx = withFieldKinds(ctx, t)
+	hashes1, pks1, err := tableRowHashes(ctx, d1, t)
+	if err != nil {
+		results <- newFinalTableResult(t.Name, fmt.Sprintf("%s cannot get data from %s: %q", result, contextLabel(ctx, 1, false), redactError(err)))
+		return
+	}
+	hashes2, pks2, err := tableRowHashes(ctx, d2, t)
+	if err != nil {
+		results <- newFinalTableResult(t.Name, fmt.Sprintf("%s cannot get data from %s: %q", result, contextLabel(ctx, 2, false), redactError(err)))
+		return
+	}
+
+	equal := true
+	line := 0
+	filter := newBaselineFilter(ctx, t.Name)
+	// addDifference adds rows hashes difference, a hash which does not exist is represented as an empty string.
+	// Rows hashes differences are accepted by the baseline as whole rows ones.
+	addDifference := func(pk, hash1, hash2 string) {
+		differences := []Difference{{Name: "row hash", Value1: shortHash(hash1), Value2: shortHash(hash2)}}
+		if differences, equalLine := filter.filter(pk, differences, true); !equalLine {
+			equal = false
+			result += fmt.Sprintf("\n  line %d (%s=%s):", line, t.PrimaryKey.Name, pk)
+			formatDifferences(ctx, &differences, &result)
+		}
+	}
+	for _, pk := range pks1 {
+		line++
+		if hash2, ok := hashes2[pk]; !ok || hash2 != hashes1[pk] {
+			addDifference(pk, hashes1[pk], hash2)
+		}
+	}
+	for _, pk := range pks2 {
+		if _, ok := hashes1[pk]; !ok {
+			line++
+			addDifference(pk, "", hashes2[pk])
+		}
+	}
+
+	if equal && verbose {
+		result += " none"
+	}
+	r := filter.apply(tableResult{table: t.Name, final: true, equal: equal})
+	if !equal || verbose || vvverbose {
+		r.text = result
+	}
+	results <- r
+}
+
+// shortHash returns leading digits of a given row hash used in comparison output.
+func shortHash(hash string) string {
+	if len(hash) > binaryDigestLength {
+		return hash[:binaryDigestLength]
+	}
+	return hash
+}
+
+// rowHash returns SHA-256 digest of given canonical values of a row in hex. Binary values are hashed first,
+// so that the digest does not depend on whether binary values are compared by their hashes only.
+func rowHash(ctx context.Context, fields []*models.Field, values []sql.NullString) string {
+	h := sha256.New()
+	for i, v := range values {
+		if !v.Valid {
+			h.Write([]byte("N"))
+			continue
+		}
+		value := v.String
+		if kindOf(ctx, fields[i]) == binaryKind && !strings.HasPrefix(value, binaryHashPrefix) {
+			value = hashBinary(value)
+		}
+		h.Write([]byte("V" + strconv.Itoa(len(value)) + ":" + value))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// snapshotWriter writes a snapshot as JSON to a file, compressed with gzip if requested. Tables are encoded
+// as soon as they are added, so that the written snapshot is not kept in memory.
+type snapshotWriter struct {
+	f      *os.File
+	gz     *gzip.Writer
+	w      *bufio.Writer
+	tables int
+}
+
+// createSnapshotWriter creates a file with a given path and returns a snapshot writer writing to it.
+func createSnapshotWriter(path string, compress bool) (*snapshotWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	sw := &snapshotWriter{f: f}
+	if compress {
+		sw.gz = gzip.NewWriter(f)
+		sw.w = bufio.NewWriter(sw.gz)
+	} else {
+		sw.w = bufio.NewWriter(f)
+	}
+	return sw, nil
+}
+
+// begin writes the snapshot header, opening the tables array.
+func (sw *snapshotWriter) begin(header *snapshotFile) error {
+	h := *header
+	h.Tables = []snapshotTable{}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	// the encoded header ends with the empty tables array
+	_, err = sw.w.Write(data[:len(data)-len("]}")])
+	return err
+}
+
+// add writes a table.
+func (sw *snapshotWriter) add(table snapshotTable) error {
+	data, err := json.Marshal(table)
+	if err != nil {
+		return err
+	}
+	if sw.tables > 0 {
+		if err = sw.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	sw.tables++
+	_, err = sw.w.Write(data)
+	return err
+}
+
+// close closes the tables array and the file. Returns the first error occurred.
+func (sw *snapshotWriter) close() error {
+	_, err := sw.w.WriteString("]}\n")
+	if err == nil {
+		err = sw.w.Flush()
+	}
+	if sw.gz != nil {
+		if gzErr := sw.gz.Close(); err == nil {
+			err = gzErr
+		}
+	}
+	if fErr := sw.f.Close(); err == nil {
+		err = fErr
+	}
+	return err
+}
+
+// readSnapshotFile reads a snapshot from a file with a given path. Compressed files are detected by their content.
+func readSnapshotFile(path string) (*snapshotFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var file snapshotFile
+	if err = json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+	if file.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", file.Version)
+	}
+	return &file, nil
+}
+
+// snapshotsLoaded is a number of snapshots loaded into in-memory databases, used in the databases names.
+var snapshotsLoaded atomic.Int64
@@ -0,0 +1,132 @@
+package dbdiff
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotFileRoundTrip(t *testing.T) {
+	value := "a"
+	file := snapshotFile{
+		Version: snapshotVersion,
+		Source:  postgresql.Name(),
+		Tables: []snapshotTable{{
+			Name:    "t",
+			Schema:  "id integer PRIMARY KEY, v text",
+			Columns: []string{"id", "v"},
+			Indexes: []snapshotIndex{{Name: "t_v", Columns: []string{"v"}, Unique: true}},
+			Rows:    [][]*string{{&value, nil}},
+		}, {
+			Name:       "u",
+			Schema:     "id integer PRIMARY KEY",
+			PrimaryKey: "id",
+			Columns:    []string{"id"},
+			Hashes:     map[string]string{"1": "abc"},
+		}},
+	}
+
+	for _, name := range []string{"snapshot.json", "snapshot.json.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			w, err := createSnapshotWriter(path, strings.HasSuffix(name, ".gz"))
+			require.NoError(t, err)
+			require.NoError(t, w.begin(&file))
+			for _, table := range file.Tables {
+				require.NoError(t, w.add(table))
+			}
+			require.NoError(t, w.close())
+			read, err := readSnapshotFile(path)
+			require.NoError(t, err)
+			require.Equal(t, file, *read)
+		})
+	}
+}
+
+func TestRowHash(t *testing.T) {
+	fields := newDDLTable("t", []string{"id integer primary key", "data blob", "v text"}).Fields
+	ctx := context.Background()
+	hash := rowHash(ctx, fields, nullStrings("1", "\x01\x02", "NULL"))
+
+	// binary values replaced with their hashes produce the same row hash
+	require.Equal(t, hash, rowHash(ctx, fields, nullStrings("1", hashBinary("\x01\x02"), "NULL")))
+	// NULL values differ from empty strings
+	require.NotEqual(t, hash, rowHash(ctx, fields, []sql.NullString{{String: "1", Valid: true}, {String: "\x01\x02", Valid: true}, {Valid: true}}))
+}
+
+func TestSnapshotSqlite(t *testing.T) {
+	statements := `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, created TIMESTAMP, pic BLOB);
+		CREATE INDEX users_name ON users (name);
+		INSERT INTO users VALUES (1, 'a', '2024-01-02 03:04:05', X'0102'), (2, NULL, NULL, NULL);`
+	input1 := createSqliteDatabase(t, "d1.db", statements)
+	input2 := createSqliteDatabase(t, "d2.db", statements+"UPDATE users SET name = 'b' WHERE id = 2;")
+
+	var tests = []struct {
+		name string
+		data bool
+	}{
+		{"row hashes", false},
+		{"data", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.json.gz")
+			ctx := context.WithValue(context.Background(), SnapshotDataContextKey, tt.data)
+			NewDatabaseSnapshotter().Snapshot(ctx, input1, path)
+
+			dc := &databaseComparer{}
+			printer, _ := dc.compare(context.Background(), snapshotPrefix+path, input1, io.Discard)
+			require.False(t, printer.differences)
+
+			var out bytes.Buffer
+			printer, _ = dc.compare(context.Background(), input2, snapshotPrefix+path, &out)
+			require.True(t, printer.differences)
+			require.True(t, strings.HasPrefix(out.String(), "Table users data differences:\n  line 2 (id=2):"))
+		})
+	}
+}
+
+func TestSnapshotTimestampOptions(t *testing.T) {
+	file := snapshotFile{Version: snapshotVersion, Source: sqlite.Name(), Timezone: "Europe/Paris", TimestampPrecision: "us"}
+	db, _, err := loadSnapshotFile(context.Background(), &file)
+	require.NoError(t, err)
+	defer db.keeper.Close()
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	require.NoError(t, db.checkTimestampOptions(timestampOptions{location: paris, precision: time.Microsecond}))
+	require.EqualError(
+		t, db.checkTimestampOptions(defaultTimestampOptions),
+		"snapshot is taken with -timezone Europe/Paris -timestamp-precision us, compare it with the same options",
+	)
+	require.Error(t, db.checkTimestampOptions(timestampOptions{location: paris, precision: time.Millisecond}))
+
+	file.Timezone = "Nowhere/Land"
+	_, _, err = loadSnapshotFile(context.Background(), &file)
+	require.ErrorContains(t, err, "invalid snapshot timestamp options")
+}
+
+func TestSnapshotTableNames(t *testing.T) {
+	input := createSqliteDatabase(t, "d.db", `
+		CREATE TABLE dbdiff_tables (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO dbdiff_tables VALUES (1, 'a');`)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	NewDatabaseSnapshotter().Snapshot(context.WithValue(context.Background(), SnapshotDataContextKey, true), input, path)
+
+	dc := &databaseComparer{}
+	printer, _ := dc.compare(context.Background(), snapshotPrefix+path, input, io.Discard)
+	require.False(t, printer.differences)
+
+	file := snapshotFile{Version: snapshotVersion, Source: sqlite.Name(), Tables: []snapshotTable{{Name: "__DBDIFF_SNAPSHOT_tables"}}}
+	_, _, err := loadSnapshotFile(context.Background(), &file)
+	require.EqualError(t, err, "table __DBDIFF_SNAPSHOT_tables name starts with __dbdiff_snapshot_ reserved for snapshot metadata")
+}
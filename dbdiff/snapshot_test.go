@@ -0,0 +1,68 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+func TestSqliteSnapshot(t *testing.T) {
+	ctx := context.Background()
+	uri := filepath.Join(t.TempDir(), "snapshot.db")
+	writer, err := sql.Open(sqlite.Driver(), uri)
+	require.NoError(t, err)
+	defer writer.Close()
+	_, err = writer.Exec("PRAGMA journal_mode=WAL; CREATE TABLE t (id INTEGER PRIMARY KEY); INSERT INTO t VALUES (1);")
+	require.NoError(t, err)
+
+	handler, err := sql.Open(sqlite.Driver(), uri)
+	require.NoError(t, err)
+	defer handler.Close()
+	d := models.Database{DBType: sqlite, Handler: handler, URI: uri}
+	require.NoError(t, d.BeginSnapshot(ctx))
+	require.Empty(t, d.Snapshot)
+
+	var count int
+	require.NoError(t, d.ScanRow(ctx, "SELECT COUNT(*) FROM t;", nil, &count))
+	require.Equal(t, 1, count)
+
+	_, err = writer.Exec("INSERT INTO t VALUES (2);")
+	require.NoError(t, err)
+
+	// reads in a joined snapshot do not observe concurrent writes either
+	joined, end, err := d.JoinSnapshot(ctx)
+	require.NoError(t, err)
+	require.Same(t, d.Tx, joined.Tx)
+	require.NoError(t, joined.ScanRow(ctx, "SELECT COUNT(*) FROM t;", nil, &count))
+	require.Equal(t, 1, count)
+	end()
+
+	d.EndSnapshot()
+	require.NoError(t, d.ScanRow(ctx, "SELECT COUNT(*) FROM t;", nil, &count))
+	require.Equal(t, 2, count)
+}
+
+func TestQueryStatementTimeout(t *testing.T) {
+	var tests = []struct {
+		name     string
+		dbType   models.DatabaseType
+		timeout  time.Duration
+		expected string
+	}{
+		{"postgres", postgresql, 30 * time.Second, "SET LOCAL statement_timeout = 30000;"},
+		{"postgres_sub_millisecond", postgresql, time.Microsecond, "SET LOCAL statement_timeout = 1;"},
+		{"sqlite", sqlite, 30 * time.Second, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.dbType.QueryStatementTimeout(tt.timeout))
+		})
+	}
+}
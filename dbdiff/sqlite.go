@@ -1,9 +1,11 @@
 package dbdiff
 
 import (
+	"database/sql"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/ygrebnov/dbdiff/models"
@@ -25,6 +27,8 @@ func (*sqliteDatabase) Driver() string {
 	return "sqlite"
 }
 
+// Parse parses SQLite table schema. Column names are lowercased, as SQLite identifiers are case-insensitive,
+// so that they match PostgreSQL unquoted ones.
 func (*sqliteDatabase) Parse(table *models.Table) {
 	sql := regexp.MustCompile(`(?s)\(.+\)`).FindString(table.Schema)
 	fields := regexp.MustCompile(`(?s)(\S+[^,]+?(,|$))`).FindAllString(sql[1:len(sql)-1], -1)
@@ -33,9 +37,10 @@ func (*sqliteDatabase) Parse(table *models.Table) {
 	}
 
 	for _, field := range fields {
+		// brackets are kept, so that arrays types, e.g. text[], are not truncated
 		field = strings.TrimFunc(
 			strings.ToLower(field),
-			func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) },
+			func(r rune) bool { return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '[' && r != ']') },
 		)
 		if !strings.HasPrefix(field, "create table") &&
 			!strings.HasPrefix(field, "foreign key") &&
@@ -46,19 +51,115 @@ func (*sqliteDatabase) Parse(table *models.Table) {
 	}
 }
 
-func (*sqliteDatabase) QueryAll() string {
-	return "SELECT name, sql FROM sqlite_master WHERE type = 'table';"
+func (*sqliteDatabase) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name)
 }
 
-func (*sqliteDatabase) QueryOne(name string) string {
-	return fmt.Sprintf("SELECT sql FROM sqlite_master WHERE name = '%s';", name)
+func (*sqliteDatabase) Placeholder(int) string {
+	return "?"
 }
 
-func (*sqliteDatabase) QueryExcluded(names []string) string {
+func (db *sqliteDatabase) QueryAll(filter *models.TableFilter) (string, []any) {
+	args := newQueryArgs(db)
 	return fmt.Sprintf(
-		"SELECT name from sqlite_master WHERE type = 'table' AND name not in (%s);",
-		strings.Join(names, ","),
+		"SELECT name, sql FROM sqlite_master WHERE type = 'table'%s%s;",
+		sqliteInternalTablesSQL,
+		tableFilterSQL("name", filter, sqliteGlobCondition, args),
+	), args.values
+}
+
+func (*sqliteDatabase) QueryOne(name string) (string, []any) {
+	return "SELECT sql FROM sqlite_master WHERE name = ?;", []any{name}
+}
+
+func (db *sqliteDatabase) QueryExcluded(names []string, filter *models.TableFilter) (string, []any) {
+	args := newQueryArgs(db)
+	placeholders := make([]string, 0, len(names))
+	for _, name := range names {
+		placeholders = append(placeholders, args.bind(name))
+	}
+	return fmt.Sprintf(
+		"SELECT name from sqlite_master WHERE type = 'table' AND name not in (%s)%s%s;",
+		strings.Join(placeholders, ","),
+		sqliteInternalTablesSQL,
+		tableFilterSQL("name", filter, sqliteGlobCondition, args),
+	), args.values
+}
+
+// QuerySize returns a query selecting given table rows number estimate: the largest rowid, which is read from the end
+// of the table b-tree, so that tables are not scanned. Tables without rowid cannot be estimated.
+func (*sqliteDatabase) QuerySize(name string) (string, []any) {
+	return fmt.Sprintf("SELECT COALESCE(MAX(rowid), 0) FROM %s;", quoteIdentifier(name)), nil
+}
+
+// QueryIndexes returns a query selecting SQLite table indexes. Indexes created by UNIQUE constraints are flagged
+// as constraints.
+func (*sqliteDatabase) QueryIndexes(name string) (string, []any) {
+	return `SELECT name, is_unique, origin = 'u', group_concat(column_name, ',')
+	FROM (
+		SELECT il.name, il."unique" AS is_unique, il.origin, lower(ii.name) AS column_name
+		FROM pragma_index_list(?) il
+		JOIN pragma_index_info(il.name) ii
+		WHERE il.origin <> 'pk'
+		ORDER BY il.name, ii.seqno
 	)
+	GROUP BY name
+	ORDER BY name;`, []any{name}
+}
+
+// QueryReferences returns a query selecting names of tables referenced by SQLite table foreign keys.
+func (*sqliteDatabase) QueryReferences(name string) (string, []any) {
+	return `SELECT DISTINCT "table" FROM pragma_foreign_key_list(?) ORDER BY 1;`, []any{name}
+}
+
+// ColumnType returns SQLite field declared type. Constraints are kept in field attributes.
+func (*sqliteDatabase) ColumnType(field *models.Field) string {
+	return field.FieldType
+}
+
+// Canonicalizers returns SQLite values canonicalizers. SQLite has no dedicated boolean storage class,
+// so that boolean values are usually stored as 0 and 1 integers or as text.
+func (*sqliteDatabase) Canonicalizers() models.Canonicalizers {
+	return sqliteCanonicalizers
+}
+
+// TxOptions returns SQLite read transactions options. The snapshot of data is established on the first read.
+// Writes to a database not in WAL mode are blocked while the transaction is open.
+func (*sqliteDatabase) TxOptions() *sql.TxOptions {
+	return &sql.TxOptions{ReadOnly: true}
+}
+
+// QueryExportSnapshot returns an empty string: SQLite snapshots cannot be shared between connections,
+// so that concurrent reads are performed in a single shared transaction.
+func (*sqliteDatabase) QueryExportSnapshot() string {
+	return ""
+}
+
+func (*sqliteDatabase) QueryImportSnapshot(string) string {
+	return ""
 }
 
+// QueryStatementTimeout returns an empty string: SQLite statements are interrupted by the client.
+func (*sqliteDatabase) QueryStatementTimeout(time.Duration) string {
+	return ""
+}
+
+var sqliteCanonicalizers = models.Canonicalizers{
+	"boolean": canonicalizeBoolean,
+	"bool":    canonicalizeBoolean,
+}
+
+// sqliteGlobCondition translates a glob pattern into an SQLite GLOB condition. Names are matched case-insensitively,
+// as [models.GlobMatch] does.
+func sqliteGlobCondition(column, pattern string, negate bool, args *queryArgs) (string, bool) {
+	operator := "GLOB"
+	if negate {
+		operator = "NOT GLOB"
+	}
+	return fmt.Sprintf("lower(%s) %s %s", column, operator, args.bind(strings.ToLower(pattern))), true
+}
+
+// sqliteInternalTablesSQL is a condition excluding SQLite internal tables, e.g. sqlite_sequence, from tables queries.
+const sqliteInternalTablesSQL = ` AND name NOT LIKE 'sqlite\_%' ESCAPE '\'`
+
 var sqlite = newSqliteDatabase()
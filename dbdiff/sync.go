@@ -0,0 +1,210 @@
+package dbdiff
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// Synchronizer is a type capable of making one entity equal to another one.
+type Synchronizer interface {
+	Sync(ctx context.Context, entity1 string, entity2 string)
+}
+
+// NewDatabaseSynchronizer returns a new object making database2 schema and data equal to database1 ones.
+func NewDatabaseSynchronizer() Synchronizer {
+	return &databaseComparer{}
+}
+
+// syncInput is a source of synchronization confirmations.
+var syncInput = bufio.NewReader(os.Stdin)
+
+// Sync makes database2 schema and data equal to database1 ones. Databases are compared and schema statements
+// are executed in a database2 transaction. Then database1 is compared with database2 read in the transaction, so that
+// data of created and altered tables is taken into account, and data statements are executed in the transaction.
+// Statements are executed in batches. The transaction is committed after confirmation, once both kinds of statements
+// are executed, and is rolled back otherwise. Finally, databases are compared once more to verify that they are
+// equal. With dry run option, statements are output instead of being applied.
+func (dc *databaseComparer) Sync(ctx context.Context, input1, input2 string) {
+	batchSize := fromContext(ctx, BatchSizeContextKey, defaultBatchSize)
+	if batchSize < 1 {
+		log.Fatalln("invalid batch size:", batchSize)
+	}
+	dryRun := fromContext(ctx, DryRunContextKey, false)
+	syncCtx := context.WithValue(ctx, syncContextKey, true)
+
+	printer, target := dc.compare(syncCtx, input1, input2, os.Stdout)
+	if ctx.Err() != nil {
+		return
+	}
+	if !printer.differences {
+		fmt.Println("databases are equal, nothing to synchronize")
+		return
+	}
+
+	if len(printer.statements) > 0 {
+		fmt.Println()
+		if err := printer.writeSchemaSQL(os.Stdout); err != nil {
+			log.Fatalln("cannot output schema statements:", err)
+		}
+	}
+	if dryRun {
+		writePatchesNotes(printer)
+		if len(executable(printer.dataStatements())) > 0 {
+			fmt.Println()
+			if err := printer.writeDataSQL(os.Stdout, target); err != nil {
+				log.Fatalln("cannot output data statements:", err)
+			}
+		}
+		return
+	}
+
+	if !dc.apply(ctx, input1, input2, printer) {
+		return
+	}
+
+	// databases are compared with the default verbosity level, so that only differences are output
+	fmt.Println("\nVerifying synchronization:")
+	verifyCtx := context.WithValue(ctx, VerboseContextKey, false)
+	verifyCtx = context.WithValue(verifyCtx, VVerboseContextKey, false)
+	verifyCtx = context.WithValue(verifyCtx, VVVerboseContextKey, false)
+	if printer, _ = dc.compare(verifyCtx, input1, input2, os.Stdout); ctx.Err() != nil {
+		return
+	}
+	if printer.differences {
+		log.Fatalf("%s still differs from %s\n", displayName(input2), displayName(input1))
+	}
+	fmt.Println("databases are equal")
+}
+
+// apply executes schema statements collected by a given printer in a single database2 transaction, gets data
+// statements by comparing database1 with database2 read in the transaction, and executes them in the transaction.
+// The transaction is committed after confirmation. Reports whether it is committed, i.e. unless the context
+// is canceled. Any failure rolls back all the statements.
+func (dc *databaseComparer) apply(ctx context.Context, input1, input2 string, printer *resultsPrinter) bool {
+	var d1, d2 models.Database
+	dc.parse(input1, &d1)
+	dc.parse(input2, &d2)
+	label := d2.Label
+
+	if err := dc.createHandler(ctx, &d2, 2); err != nil {
+		log.Fatalln(err)
+	}
+	defer d2.Handler.Close()
+
+	tx, err := d2.Handler.BeginTx(ctx, nil)
+	if err != nil {
+		log.Fatalf("cannot start %s transaction: %v\n", label, redactError(err))
+	}
+	// rolling back the committed transaction has no effect
+	defer func() { _ = tx.Rollback() }()
+	fail := func(kind string, err error) {
+		_ = tx.Rollback()
+		log.Fatalf("cannot apply %s statements, all the statements are rolled back: %v\n", kind, err)
+	}
+
+	schema := executable(printer.schemaStatements())
+	if len(schema) > 0 {
+		if err = execute(ctx, tx, "", schema); err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			fail("schema", err)
+		}
+		// data of created and altered tables is compared with the updated schema
+		d2.Tx = tx
+		if printer, _, err = dc.compareDatabases(context.WithValue(ctx, syncContextKey, true), d1, d2, io.Discard); err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			_ = tx.Rollback()
+			log.Fatalln("cannot compare data, all the statements are rolled back:", err)
+		}
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	writePatchesNotes(printer)
+	data := executable(printer.dataStatements())
+	if len(schema) == 0 && len(data) == 0 {
+		return true
+	}
+	question := fmt.Sprintf("Apply %d schema and %d data statements to %s?", len(schema), len(data), label)
+	if !fromContext(ctx, AssumeYesContextKey, false) && !confirm(question) {
+		_ = tx.Rollback()
+		log.Fatalln("synchronization canceled, all the statements are rolled back")
+	}
+	if len(data) > 0 {
+		if err = execute(ctx, tx, deferForeignKeysSQL(d2.DBType), data); err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			fail("data", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		log.Fatalf("cannot commit statements: %v\n", err)
+	}
+	fmt.Printf("%d schema and %d data statements applied\n", len(schema), len(data))
+	return true
+}
+
+// execute executes given statements in a given transaction in batches of configured size.
+// A given prologue statement, if any, is executed first.
+func execute(ctx context.Context, tx *sql.Tx, prologue string, statements []string) error {
+	if len(prologue) > 0 {
+		if _, err := tx.ExecContext(ctx, prologue); err != nil {
+			return err
+		}
+	}
+	batchSize := fromContext(ctx, BatchSizeContextKey, defaultBatchSize)
+	for i := 0; i < len(statements); i += batchSize {
+		end := i + batchSize
+		if end > len(statements) {
+			end = len(statements)
+		}
+		if _, err := tx.ExecContext(ctx, strings.Join(statements[i:end], "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePatchesNotes logs notes of data patches collected by a given printer, e.g. on skipped statements.
+func writePatchesNotes(printer *resultsPrinter) {
+	for table, patch := range printer.patches {
+		for _, note := range patch.notes {
+			log.Printf("table %s: %s\n", table, strings.TrimPrefix(note, "-- "))
+		}
+	}
+}
+
+// confirm asks a given question and reports whether it is answered positively.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := syncInput.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// executable returns given statements except comments.
+func executable(statements []string) []string {
+	var result []string
+	for _, s := range statements {
+		if !strings.HasPrefix(s, "--") {
+			result = append(result, s)
+		}
+	}
+	return result
+}
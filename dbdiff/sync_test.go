@@ -0,0 +1,88 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// createSqliteDatabase creates an SQLite database file with given statements executed and returns its input string.
+func createSqliteDatabase(t *testing.T, name, statements string) string {
+	uri := filepath.Join(t.TempDir(), name)
+	db, err := sql.Open(sqlite.Driver(), uri)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(statements)
+	require.NoError(t, err)
+	return sqlite.Name() + ":" + uri
+}
+
+func TestSyncSqlite(t *testing.T) {
+	input1 := createSqliteDatabase(t, "d1.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id), total REAL);
+		CREATE TABLE tags (id INTEGER PRIMARY KEY, tag TEXT);
+		INSERT INTO users VALUES (1, 'a'), (2, 'o''b'), (3, 'c');
+		INSERT INTO orders VALUES (1, 1, 9.5), (2, 3, 1);
+		INSERT INTO tags VALUES (1, 'x');`,
+	)
+	input2 := createSqliteDatabase(t, "d2.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, legacy TEXT);
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id), total REAL);
+		INSERT INTO users VALUES (1, 'a', NULL), (2, 'ob', NULL), (4, 'd', NULL);
+		INSERT INTO orders VALUES (1, 1, 9.5), (3, 4, 2);`,
+	)
+
+	ctx := context.WithValue(context.Background(), AssumeYesContextKey, true)
+	ctx = context.WithValue(ctx, SchemaSQLDestructiveContextKey, true)
+	ctx = context.WithValue(ctx, BatchSizeContextKey, 2)
+	NewDatabaseSynchronizer().Sync(ctx, input1, input2)
+
+	printer, _ := (&databaseComparer{}).compare(context.Background(), input1, input2, io.Discard)
+	require.False(t, printer.differences)
+}
+
+func TestCompareInTransactionSqlite(t *testing.T) {
+	input1 := createSqliteDatabase(t, "d1.db", `
+		CREATE TABLE tags (id INTEGER PRIMARY KEY, tag TEXT);
+		INSERT INTO tags VALUES (1, 'x');`,
+	)
+	input2 := createSqliteDatabase(t, "d2.db", "CREATE TABLE users (id INTEGER PRIMARY KEY);")
+
+	dc := &databaseComparer{}
+	var d1, d2 models.Database
+	dc.parse(input1, &d1)
+	dc.parse(input2, &d2)
+	require.NoError(t, dc.createHandler(context.Background(), &d2, 2))
+	defer d2.Handler.Close()
+	tx, err := d2.Handler.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("CREATE TABLE tags (id INTEGER PRIMARY KEY, tag TEXT);")
+	require.NoError(t, err)
+
+	// database2 read in the transaction holds the created table, which data is compared
+	d2.Tx = tx
+	ctx := context.WithValue(context.Background(), syncContextKey, true)
+	printer, _, err := dc.compareDatabases(ctx, d1, d2, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, []string{`INSERT INTO "tags" ("id", "tag") VALUES ('1', 'x');`}, printer.dataStatements())
+
+	// rolled back statements are not applied
+	require.NoError(t, tx.Rollback())
+	printer, _ = dc.compare(context.Background(), input1, input2, io.Discard)
+	require.Equal(t, map[string]bool{"tags": true, "users": true}, printer.differing)
+}
+
+func TestExecutable(t *testing.T) {
+	require.Equal(
+		t,
+		[]string{"CREATE TABLE t (id integer);", "DROP INDEX i;"},
+		executable([]string{"CREATE TABLE t (id integer);", "-- DROP TABLE u;", "DROP INDEX i;", "-- note"}),
+	)
+}
@@ -0,0 +1,219 @@
+package dbdiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// ThreeWayComparer is a type capable of comparing two entities derived from a common base one
+// and reporting whether their changes conflict.
+type ThreeWayComparer interface {
+	CompareThreeWay(ctx context.Context, base, left, right string) bool
+}
+
+// NewThreeWayComparer returns a new object comparing two databases derived from a common base one.
+func NewThreeWayComparer() ThreeWayComparer {
+	return &databaseComparer{}
+}
+
+// Three-way comparison changes kinds.
+const (
+	leftOnlyChange    = "left-only"
+	rightOnlyChange   = "right-only"
+	bothSameChange    = "both-same"
+	conflictingChange = "conflicting"
+)
+
+// change is a difference of a database from the base one. Name is a field name, empty for a table presence
+// difference. Key is a row primary key value, empty for table presence and schema differences.
+type change struct {
+	table, name, key string
+	base, value      string
+}
+
+// threeWayChange is a classified difference of left and right databases from the base one.
+type threeWayChange struct {
+	table, name, key  string
+	kind              string
+	base, left, right string
+}
+
+// presence returns a table presence representation in three-way comparison output.
+func presence(exists bool) string {
+	if exists {
+		return "exists"
+	}
+	return "absent"
+}
+
+// CompareThreeWay compares left and right databases with the base one, classifies each difference as made
+// in the left database only, in the right one only, in both of them the same way, or conflicting, outputs
+// the classified differences and reports whether any of them conflict.
+// Databases are compared with the default verbosity level, without the baseline and statements generation.
+// Data of tables which schemas differ from the base one is compared on the columns existing in both databases.
+func (dc *databaseComparer) CompareThreeWay(ctx context.Context, base, left, right string) bool {
+	compareCtx := context.WithValue(ctx, recordChangesContextKey, true)
+	compareCtx = context.WithValue(compareCtx, commonColumnsContextKey, true)
+	for _, key := range []contextKey{VerboseContextKey, VVerboseContextKey, VVVerboseContextKey, UpdateBaselineContextKey} {
+		compareCtx = context.WithValue(compareCtx, key, false)
+	}
+	for _, key := range []contextKey{BaselineContextKey, EmitSchemaSQLContextKey, EmitDataSQLContextKey} {
+		compareCtx = context.WithValue(compareCtx, key, "")
+	}
+
+	leftPrinter, _ := dc.compare(compareCtx, base, left, io.Discard)
+	if ctx.Err() != nil {
+		return false
+	}
+	rightPrinter, _ := dc.compare(compareCtx, base, right, io.Discard)
+	if ctx.Err() != nil {
+		return false
+	}
+
+	changes := classifyChanges(leftPrinter.changes, rightPrinter.changes)
+	writeThreeWayChanges(os.Stdout, changes)
+	for _, c := range changes {
+		if c.kind == conflictingChange {
+			return true
+		}
+	}
+	return false
+}
+
+// changeID identifies a change of a table, a field, a row, or a row field.
+type changeID struct {
+	table, name, key string
+}
+
+// classifyChanges classifies given changes of left and right databases from the base one. Changes of the same
+// table field, row, or row field are made in both databases either the same way, or in a conflicting one.
+// Changes of a table dropped in one of the databases conflict with any changes of the table in the other one.
+// Classified changes are sorted by table name, table presence and schema changes first, then by row.
+func classifyChanges(left, right []change) []threeWayChange {
+	rights := make(map[changeID]change, len(right))
+	for _, r := range right {
+		rights[changeID{r.table, r.name, r.key}] = r
+	}
+
+	classified := make([]threeWayChange, 0, len(left)+len(right))
+	for _, l := range left {
+		id := changeID{l.table, l.name, l.key}
+		c := threeWayChange{
+			table: l.table, name: l.name, key: l.key, kind: leftOnlyChange, base: l.base, left: l.value, right: l.base,
+		}
+		if r, ok := rights[id]; ok {
+			delete(rights, id)
+			c.right = r.value
+			c.kind = conflictingChange
+			if r.value == l.value {
+				c.kind = bothSameChange
+			}
+		}
+		classified = append(classified, c)
+	}
+	for _, r := range right {
+		if _, ok := rights[changeID{r.table, r.name, r.key}]; ok {
+			classified = append(classified, threeWayChange{
+				table: r.table, name: r.name, key: r.key, kind: rightOnlyChange, base: r.base, left: r.base, right: r.value,
+			})
+		}
+	}
+
+	// tables dropped in one of the databases only
+	dropped := make(map[string]string)
+	for _, c := range classified {
+		if len(c.name) == 0 && len(c.key) == 0 && c.base == presence(true) &&
+			(c.kind == leftOnlyChange || c.kind == rightOnlyChange) {
+			dropped[c.table] = c.kind
+		}
+	}
+	for i, c := range classified {
+		if kind, ok := dropped[c.table]; ok && (len(c.name) > 0 || len(c.key) > 0) && c.kind != kind {
+			classified[i].kind = conflictingChange
+			if kind == leftOnlyChange {
+				classified[i].left = ""
+			} else {
+				classified[i].right = ""
+			}
+		}
+	}
+
+	sort.SliceStable(classified, func(i, j int) bool {
+		if classified[i].table != classified[j].table {
+			return classified[i].table < classified[j].table
+		}
+		if classified[i].key == classified[j].key {
+			// a table presence change precedes its schema changes
+			return len(classified[i].name) == 0 && len(classified[j].name) > 0
+		}
+		return keyLess(classified[i].key, classified[j].key)
+	})
+	return classified
+}
+
+// keyLess reports whether primary key value k1 sorts before k2. Empty values sort first,
+// integer values are compared numerically.
+func keyLess(k1, k2 string) bool {
+	if len(k1) == 0 || len(k2) == 0 {
+		return len(k1) == 0 && len(k2) > 0
+	}
+	n1, err1 := strconv.ParseInt(k1, 10, 64)
+	n2, err2 := strconv.ParseInt(k2, 10, 64)
+	if err1 == nil && err2 == nil {
+		return n1 < n2
+	}
+	return k1 < k2
+}
+
+// writeThreeWayChanges writes given sorted changes grouped by table and row, followed by numbers of changes by kind.
+func writeThreeWayChanges(w io.Writer, changes []threeWayChange) {
+	counts := make(map[string]int)
+	for i := 0; i < len(changes); {
+		c := changes[i]
+		if i == 0 || c.table != changes[i-1].table {
+			fmt.Fprintf(w, "Table %s:\n", c.table)
+		}
+		tw := tabwriter.NewWriter(w, 5, 0, 3, ' ', 0)
+		switch {
+		case len(c.key) > 0:
+			fmt.Fprintf(w, "  row %s:\n", c.key)
+			fmt.Fprintln(tw, "    Field\tChange\tBase\tLeft\tRight")
+		case len(c.name) > 0:
+			fmt.Fprintln(w, "  schema:")
+			fmt.Fprintln(tw, "    Field\tChange\tBase\tLeft\tRight")
+		default:
+			fmt.Fprintln(w, "  table:")
+			fmt.Fprintln(tw, "    Change\tBase\tLeft\tRight")
+		}
+		// changes of the same table presence, schema, or row are written together
+		for ; i < len(changes) && sameChangeGroup(c, changes[i]); i++ {
+			g := changes[i]
+			counts[g.kind]++
+			if len(g.name) == 0 && len(g.key) == 0 {
+				fmt.Fprintf(tw, "    %s\t%s\t%s\t%s\n", g.kind, g.base, g.left, g.right)
+				continue
+			}
+			fmt.Fprintf(tw, "    %s\t%s\t%s\t%s\t%s\n", g.name, g.kind, g.base, g.left, g.right)
+		}
+		tw.Flush()
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes from base")
+	}
+	fmt.Fprintf(
+		w, "%s: %d, %s: %d, %s: %d, %s: %d\n",
+		leftOnlyChange, counts[leftOnlyChange], rightOnlyChange, counts[rightOnlyChange],
+		bothSameChange, counts[bothSameChange], conflictingChange, counts[conflictingChange],
+	)
+}
+
+// sameChangeGroup reports whether two given changes are of the same table presence, schema, or row.
+func sameChangeGroup(c1, c2 threeWayChange) bool {
+	return c1.table == c2.table && c1.key == c2.key && (len(c1.name) == 0) == (len(c2.name) == 0)
+}
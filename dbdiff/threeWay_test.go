@@ -0,0 +1,99 @@
+package dbdiff
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyChanges(t *testing.T) {
+	left := []change{
+		{table: "users", name: "name", key: "10", base: "a", value: "b"},
+		{table: "users", name: "name", key: "2", base: "a", value: "b"},
+		{table: "users", name: "legacy", value: "text"},
+		{table: "logs", base: "exists", value: "absent"},
+	}
+	right := []change{
+		{table: "users", name: "name", key: "2", base: "a", value: "c"},
+		{table: "users", name: "legacy", value: "text"},
+		{table: "users", name: "email", key: "3", base: "x", value: "y"},
+		{table: "logs", name: "msg", key: "1", base: "x", value: "y"},
+		{table: "tags", base: "absent", value: "exists"},
+	}
+
+	require.Equal(t, []threeWayChange{
+		{table: "logs", kind: leftOnlyChange, base: "exists", left: "absent", right: "exists"},
+		{table: "logs", name: "msg", key: "1", kind: conflictingChange, base: "x", right: "y"},
+		{table: "tags", kind: rightOnlyChange, base: "absent", left: "absent", right: "exists"},
+		{table: "users", name: "legacy", kind: bothSameChange, left: "text", right: "text"},
+		{table: "users", name: "name", key: "2", kind: conflictingChange, base: "a", left: "b", right: "c"},
+		{table: "users", name: "email", key: "3", kind: rightOnlyChange, base: "x", left: "x", right: "y"},
+		{table: "users", name: "name", key: "10", kind: leftOnlyChange, base: "a", left: "b", right: "a"},
+	}, classifyChanges(left, right))
+}
+
+func TestWriteThreeWayChanges(t *testing.T) {
+	var out bytes.Buffer
+	writeThreeWayChanges(&out, []threeWayChange{
+		{table: "logs", kind: leftOnlyChange, base: "exists", left: "absent", right: "exists"},
+		{table: "users", name: "legacy", kind: bothSameChange, left: "text", right: "text"},
+		{table: "users", name: "name", key: "2", kind: conflictingChange, base: "a", left: "b", right: "c"},
+	})
+	require.Equal(t, "Table logs:\n"+
+		"  table:\n"+
+		"    Change      Base     Left     Right\n"+
+		"    left-only   exists   absent   exists\n"+
+		"Table users:\n"+
+		"  schema:\n"+
+		"    Field    Change      Base   Left   Right\n"+
+		"    legacy   both-same          text   text\n"+
+		"  row 2:\n"+
+		"    Field   Change        Base   Left   Right\n"+
+		"    name    conflicting   a      b      c\n"+
+		"left-only: 1, right-only: 0, both-same: 1, conflicting: 1\n", out.String())
+}
+
+func TestCompareThreeWayChangesSqlite(t *testing.T) {
+	base := createSqliteDatabase(t, "base.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users VALUES (1, 'a'), (2, 'b');`,
+	)
+	left := createSqliteDatabase(t, "left.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE tags (id INTEGER PRIMARY KEY);
+		INSERT INTO users VALUES (1, 'A');`,
+	)
+
+	// differences are recorded regardless of the verbosity level
+	ctx := context.WithValue(context.Background(), recordChangesContextKey, true)
+	printer, _ := (&databaseComparer{}).compare(context.WithValue(ctx, VVVerboseContextKey, true), base, left, io.Discard)
+	require.ElementsMatch(t, []change{
+		{table: "tags", base: "absent", value: "exists"},
+		{table: "users", name: "name", key: "1", base: "a", value: "A"},
+		{table: "users", name: "id", key: "2", base: "2"},
+		{table: "users", name: "name", key: "2", base: "b"},
+	}, printer.changes)
+}
+
+func TestCompareThreeWayChangesCommonColumnsSqlite(t *testing.T) {
+	base := createSqliteDatabase(t, "base.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users VALUES (1, 'a'), (2, 'b');`,
+	)
+	left := createSqliteDatabase(t, "left.db", `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT);
+		INSERT INTO users VALUES (1, 'A', 'a@example.com'), (2, 'b', NULL);`,
+	)
+
+	// data of tables which schemas differ is compared on common columns
+	ctx := context.WithValue(context.Background(), recordChangesContextKey, true)
+	ctx = context.WithValue(ctx, commonColumnsContextKey, true)
+	printer, _ := (&databaseComparer{}).compare(ctx, base, left, io.Discard)
+	require.ElementsMatch(t, []change{
+		{table: "users", name: "email", value: "text"},
+		{table: "users", name: "name", key: "1", base: "a", value: "A"},
+	}, printer.changes)
+}
@@ -0,0 +1,128 @@
+package dbdiff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timestampOptions holds timestamp values comparison options.
+type timestampOptions struct {
+	// location is a time zone of timestamp values without explicit offsets.
+	location *time.Location
+	// precision is a duration timestamp and time values are truncated to.
+	precision time.Duration
+}
+
+// defaultTimestampOptions are used if no timestamp options are set in context.
+var defaultTimestampOptions = timestampOptions{location: time.UTC, precision: time.Nanosecond}
+
+// timestampPrecisions maps supported timestamp precision names to durations.
+var timestampPrecisions = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+// timestampLayouts are layouts of timestamp values representations returned by database drivers
+// or stored as text in SQLite databases. Layouts with explicit offsets come first.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// timeLayouts are layouts of time of day values representations.
+var timeLayouts = []string{
+	"15:04:05.999999999Z07:00",
+	"15:04:05.999999999Z07",
+	"15:04:05.999999999",
+	"15:04",
+}
+
+// parseTimestampOptions parses timestamp values comparison options set in context.
+func parseTimestampOptions(ctx context.Context) (timestampOptions, error) {
+	options := defaultTimestampOptions
+
+	if name := fromContext(ctx, TimezoneContextKey, ""); len(name) > 0 {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			return options, fmt.Errorf("invalid time zone %q: %w", name, err)
+		}
+		options.location = location
+	}
+
+	if name := fromContext(ctx, TimestampPrecisionContextKey, ""); len(name) > 0 {
+		precision, ok := timestampPrecisions[name]
+		if !ok {
+			return options, fmt.Errorf("invalid timestamp precision %q, expected one of: s, ms, us, ns", name)
+		}
+		options.precision = precision
+	}
+
+	return options, nil
+}
+
+// normalizeTimestamp returns a canonical representation of a timestamp, date or time value.
+// Timestamps are converted to UTC instants formatted as RFC 3339 strings. Values without explicit offsets
+// are considered to be in the configured time zone. Dates are formatted as YYYY-MM-DD, times of day as HH:MM:SS.
+// Times of day with time zone are converted to UTC, see [timeOfDayInUTC], and formatted as HH:MM:SSZ.
+// Timestamps and times are truncated to the configured precision. Values which cannot be parsed are returned as is.
+func normalizeTimestamp(kind valueKind, value string, options timestampOptions) string {
+	s := strings.TrimSpace(value)
+
+	t, ok := time.Time{}, false
+	if kind == timeKind || kind == timeTZKind {
+		t, ok = parseTime(s, timeLayouts, options.location)
+	}
+	if !ok {
+		// times may also be returned by drivers as timestamps on a zero date
+		if t, ok = parseTime(s, timestampLayouts, options.location); !ok {
+			return value
+		}
+	}
+
+	switch kind {
+	case dateKind:
+		return t.Format("2006-01-02")
+	case timeKind:
+		return t.Truncate(options.precision).Format("15:04:05.999999999")
+	case timeTZKind:
+		return timeOfDayInUTC(t, options.location).Truncate(options.precision).Format("15:04:05.999999999Z07:00")
+	default:
+		return t.In(time.UTC).Truncate(options.precision).Format(time.RFC3339Nano)
+	}
+}
+
+// timeOfDayDate is a date times of day without explicit offsets are anchored to, the PostgreSQL epoch.
+var timeOfDayDate = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// timeOfDayInUTC returns a given time of day with time zone converted to UTC. Times without explicit offsets, parsed
+// in a given location, are considered to be in the location on a fixed date, see [timeOfDayDate], so that
+// comparison results and snapshots rows hashes do not depend on the current date, e.g. on daylight saving time.
+func timeOfDayInUTC(t time.Time, location *time.Location) time.Time {
+	if t.Location() == location {
+		y, m, d := timeOfDayDate.Date()
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+	}
+	return t.In(time.UTC)
+}
+
+// parseTime parses a value with the first matching layout. Values without explicit offsets are parsed in a given location.
+func parseTime(value string, layouts []string, location *time.Location) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
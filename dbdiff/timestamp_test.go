@@ -0,0 +1,70 @@
+package dbdiff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTimestamp(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	var (
+		inParis      = timestampOptions{location: paris, precision: time.Nanosecond}
+		seconds      = timestampOptions{location: time.UTC, precision: time.Second}
+		milliseconds = timestampOptions{location: time.UTC, precision: time.Millisecond}
+		plusTwo      = timestampOptions{location: time.FixedZone("UTC+2", 2*60*60), precision: time.Nanosecond}
+	)
+
+	var tests = []struct {
+		name     string
+		kind     valueKind
+		value    string
+		options  timestampOptions
+		expected string
+	}{
+		{"sqlite_text", timestampKind, "2023-01-02 10:00:00", defaultTimestampOptions, "2023-01-02T10:00:00Z"},
+		{"driver_utc", timestampKind, "2023-01-02T10:00:00Z", defaultTimestampOptions, "2023-01-02T10:00:00Z"},
+		{"offset", timestampKind, "2023-01-02 12:00:00+02", defaultTimestampOptions, "2023-01-02T10:00:00Z"},
+		{"assumed_time_zone", timestampKind, "2023-01-02 11:00:00", inParis, "2023-01-02T10:00:00Z"},
+		{"explicit_offset_over_time_zone", timestampKind, "2023-01-02T10:00:00Z", inParis, "2023-01-02T10:00:00Z"},
+		{"truncated_seconds", timestampKind, "2023-01-02 10:00:00.987654", seconds, "2023-01-02T10:00:00Z"},
+		{"truncated_milliseconds", timestampKind, "2023-01-02T10:00:00.987654Z", milliseconds, "2023-01-02T10:00:00.987Z"},
+		{"date_text", dateKind, "2023-01-02", defaultTimestampOptions, "2023-01-02"},
+		{"date_driver", dateKind, "2023-01-02T00:00:00Z", inParis, "2023-01-02"},
+		{"time_text", timeKind, "10:00:00.5", seconds, "10:00:00"},
+		{"time_driver", timeKind, "0000-01-01T10:00:00Z", defaultTimestampOptions, "10:00:00"},
+		{"time_offset_kept", timeKind, "10:00:00+02", defaultTimestampOptions, "10:00:00"},
+		{"timetz_text", timeTZKind, "10:00:00+02", defaultTimestampOptions, "08:00:00Z"},
+		{"timetz_driver", timeTZKind, "0000-01-01T10:00:00.5+02:00", seconds, "08:00:00Z"},
+		{"timetz_utc", timeTZKind, "08:00:00Z", defaultTimestampOptions, "08:00:00Z"},
+		{"timetz_assumed_time_zone", timeTZKind, "10:00:00", plusTwo, "08:00:00Z"},
+		{"timetz_assumed_time_zone_fixed_date", timeTZKind, "10:00:00", inParis, "09:00:00Z"},
+		{"timetz_wrapping", timeTZKind, "01:00:00+03", defaultTimestampOptions, "22:00:00Z"},
+		{"not_a_timestamp", timestampKind, "yesterday", defaultTimestampOptions, "yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, normalizeTimestamp(tt.kind, tt.value, tt.options))
+		})
+	}
+}
+
+func TestParseTimestampOptions(t *testing.T) {
+	options, err := parseTimestampOptions(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, defaultTimestampOptions, options)
+
+	ctx := context.WithValue(context.Background(), TimestampPrecisionContextKey, "ms")
+	options, err = parseTimestampOptions(context.WithValue(ctx, TimezoneContextKey, "UTC"))
+	require.NoError(t, err)
+	require.Equal(t, time.Millisecond, options.precision)
+
+	_, err = parseTimestampOptions(context.WithValue(context.Background(), TimestampPrecisionContextKey, "h"))
+	require.Error(t, err)
+	_, err = parseTimestampOptions(context.WithValue(context.Background(), TimezoneContextKey, "Nowhere/Land"))
+	require.Error(t, err)
+}
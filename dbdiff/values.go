@@ -0,0 +1,189 @@
+package dbdiff
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ygrebnov/dbdiff/models"
+)
+
+// valueKind is a kind of field values defining how values are compared.
+type valueKind int
+
+const (
+	textKind valueKind = iota
+	integerKind
+	decimalKind
+	floatKind
+	timestampKind
+	dateKind
+	timeKind
+	timeTZKind
+	jsonKind
+	binaryKind
+	arrayKind
+	compositeKind
+)
+
+// valueKinds maps field types to kinds of their values. Field types not listed are compared as text.
+var valueKinds = map[string]valueKind{
+	"int":          integerKind,
+	"integer":      integerKind,
+	"int2":         integerKind,
+	"int4":         integerKind,
+	"int8":         integerKind,
+	"smallint":     integerKind,
+	"bigint":       integerKind,
+	"tinyint":      integerKind,
+	"mediumint":    integerKind,
+	"serial":       integerKind,
+	"bigserial":    integerKind,
+	"numeric":      decimalKind,
+	"decimal":      decimalKind,
+	"real":         floatKind,
+	"float":        floatKind,
+	"float4":       floatKind,
+	"float8":       floatKind,
+	"double":       floatKind,
+	"timestamp":    timestampKind,
+	"timestamptz":  timestampKind,
+	"datetime":     timestampKind,
+	"date":         dateKind,
+	"time":         timeKind,
+	"timetz":       timeTZKind,
+	"json":         jsonKind,
+	"jsonb":        jsonKind,
+	"blob":         binaryKind,
+	"bytea":        binaryKind,
+	"binary":       binaryKind,
+	"varbinary":    binaryKind,
+	"array":        arrayKind,
+	"user-defined": compositeKind,
+}
+
+// kindOf returns a kind of values of a given field. Kinds of fields of the currently compared table set in context
+// take precedence over the ones defined by field types. Type modifiers, e.g. precision and scale, are not taken into account.
+// Fields of types declared as type[] are arrays. PostgreSQL "time with time zone" fields are times with time zone.
+func kindOf(ctx context.Context, field *models.Field) valueKind {
+	if kind, ok := fromContext(ctx, fieldKindsContextKey, map[string]valueKind(nil))[field.Name]; ok {
+		return kind
+	}
+	if strings.HasSuffix(field.FieldType, "[]") {
+		return arrayKind
+	}
+	fieldType, _, _ := strings.Cut(field.FieldType, "(")
+	kind := valueKinds[fieldType]
+	if kind == timeKind && strings.HasPrefix(field.Attrs, "with time zone") {
+		return timeTZKind
+	}
+	return kind
+}
+
+// withFieldKinds returns a copy of context holding kinds of values of a given table fields, which are not defined
+// by field types. These are fields opted in to be compared as JSON documents.
+func withFieldKinds(ctx context.Context, t *models.Table) context.Context {
+	kinds := make(map[string]valueKind)
+	jsonColumns := models.NewColumnFilter(fromContext(ctx, JSONColumnsContextKey, []string(nil)))
+	for _, f := range t.Fields {
+		if jsonColumns.Match(t.Name, f.Name) {
+			kinds[f.Name] = jsonKind
+		}
+	}
+	return context.WithValue(ctx, fieldKindsContextKey, kinds)
+}
+
+// compareValues reports whether two given existing values of a field are equal.
+// NULL values are equal only to each other. Other values are compared according to the field values kind.
+// For values of structured kinds, e.g. JSON documents or arrays, details of their differences are also returned.
+func compareValues(ctx context.Context, field *models.Field, value1, value2 sql.NullString) (bool, []Difference) {
+	if !value1.Valid || !value2.Valid {
+		return value1.Valid == value2.Valid, nil
+	}
+	if value1.String == value2.String {
+		return true, nil
+	}
+
+	switch kind := kindOf(ctx, field); kind {
+	case integerKind, decimalKind, floatKind:
+		return numericValuesEqual(kind, value1.String, value2.String, numericToleranceFromContext(ctx)), nil
+	case jsonKind:
+		details, ok := jsonDifferences(field.Name, value1.String, value2.String)
+		if !ok {
+			return false, nil
+		}
+		return len(details) == 0, details
+	case arrayKind, compositeKind:
+		details, ok := arrayDifferences(ctx, field, kind, value1.String, value2.String, fromContext(ctx, JSONArraysContextKey, false))
+		if !ok {
+			return false, nil
+		}
+		return len(details) == 0, details
+	default:
+		return false, nil
+	}
+}
+
+// newValueConverter returns a converter of field values retrieved from a database of a given type into their canonical
+// representations used in comparison. Values are first converted with the database type canonicalizers,
+// then timestamp, date and time values are normalized, see [normalizeTimestamp], and binary values are replaced
+// with their hashes, if requested. Binary values are decoded while scanned, see [binaryValue].
+func newValueConverter(ctx context.Context, dbType models.DatabaseType) models.ValueConverter {
+	options := fromContext(ctx, timestampOptionsContextKey, defaultTimestampOptions)
+	hashBinaries := fromContext(ctx, BinaryHashOnlyContextKey, false)
+	canonicalizers := dbType.Canonicalizers()
+	return func(field *models.Field, value string) string {
+		value = canonicalizers.Canonicalize(field, value)
+		switch kind := kindOf(ctx, field); kind {
+		case timestampKind, dateKind, timeKind, timeTZKind:
+			return normalizeTimestamp(kind, value, options)
+		case binaryKind:
+			if hashBinaries {
+				return hashBinary(value)
+			}
+			return value
+		default:
+			return value
+		}
+	}
+}
+
+// primaryKeyIdentity returns a representation of a given primary key value identifying a table row regardless
+// of the database it is retrieved from. The value is converted with a given converter of the database values,
+// then numbers are formatted canonically, so that e.g. 10 and 10.0 are equal, and UUIDs are lowercased.
+func primaryKeyIdentity(ctx context.Context, field *models.Field, convert models.ValueConverter, value string) string {
+	value = convert(field, value)
+	switch kindOf(ctx, field) {
+	case integerKind, decimalKind:
+		if r, ok := new(big.Rat).SetString(strings.TrimSpace(value)); ok {
+			return r.RatString()
+		}
+	case floatKind:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	case textKind:
+		if field.FieldType == "uuid" {
+			return strings.ToLower(value)
+		}
+	}
+	return value
+}
+
+// formatValue returns a value of a given field representation used in comparison output.
+// NULL is represented as "NULL", a value which does not exist is represented as an empty string.
+// Binary values are represented with their leading bytes in hex, length and hash, see [formatBinary].
+func formatValue(ctx context.Context, field *models.Field, value sql.NullString, exists bool) string {
+	switch {
+	case !exists:
+		return ""
+	case !value.Valid:
+		return nullValue
+	case kindOf(ctx, field) == binaryKind:
+		return formatBinary(value.String, fromContext(ctx, BinaryHashOnlyContextKey, false))
+	default:
+		return value.String
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/lib/pq v1.10.7
 	github.com/stretchr/testify v1.8.2
 	github.com/ygrebnov/testutils v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.19.4
 )
 
@@ -30,7 +31,6 @@ require (
 	golang.org/x/net v0.0.0-20220722155237-a158d28d115b // indirect
 	golang.org/x/sys v0.1.0 // indirect
 	golang.org/x/tools v0.1.12 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	lukechampine.com/uint128 v1.2.0 // indirect
 	modernc.org/cc/v3 v3.40.0 // indirect
 	modernc.org/ccgo/v3 v3.16.13 // indirect
@@ -1,8 +1,10 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 // DatabaseType defines a database type by its name, driver, the tables schemas and data queries,
@@ -14,12 +16,48 @@ type DatabaseType interface {
 	Driver() string
 	// Parse parses table schema.
 	Parse(table *Table)
-	// QueryAll returns a query selecting all tables names and schemas.
-	QueryAll() string
-	// QueryOne returns a query selecting given table schema.
-	QueryOne(name string) string
-	// QueryExcluded returns a query selecting names of all tables except excluded.
-	QueryExcluded(names []string) string
+	// QuoteIdentifier returns a given table or column name quoted, so that it can be safely used in queries.
+	QuoteIdentifier(name string) string
+	// Placeholder returns a placeholder of a query parameter with a given 1-based index.
+	Placeholder(n int) string
+	// QueryAll returns a query selecting names and schemas of all tables passing given filter, and its parameters.
+	QueryAll(filter *TableFilter) (string, []any)
+	// QueryOne returns a query selecting given table schema, and its parameters.
+	QueryOne(name string) (string, []any)
+	// QueryExcluded returns a query selecting names of all tables passing given filter except excluded,
+	// and its parameters.
+	QueryExcluded(names []string, filter *TableFilter) (string, []any)
+	// QuerySize returns a query selecting given table number of rows or its estimate, and its parameters.
+	QuerySize(name string) (string, []any)
+	// QueryIndexes returns a query selecting given table indexes, except the primary key one, and its parameters.
+	// Each row holds index name, uniqueness and unique constraint flags, and comma-separated indexed columns names.
+	QueryIndexes(name string) (string, []any)
+	// QueryReferences returns a query selecting names of tables referenced by given table foreign keys,
+	// and its parameters.
+	QueryReferences(name string) (string, []any)
+	// ColumnType returns a full type of a given field, as it is defined in a database of the type.
+	ColumnType(field *Field) string
+	// Canonicalizers returns converters of field values into canonical representations by field type, so that
+	// equal values retrieved from databases of different types have equal representations.
+	Canonicalizers() Canonicalizers
+	// TxOptions returns options of transactions database reads are performed in, so that they observe
+	// a consistent snapshot of data.
+	TxOptions() *sql.TxOptions
+	// QueryExportSnapshot returns a query exporting a transaction snapshot identifier, so that the snapshot can be
+	// imported by other transactions. Returns an empty string if snapshots cannot be shared between transactions.
+	QueryExportSnapshot() string
+	// QueryImportSnapshot returns a query importing a snapshot with a given identifier into a transaction.
+	QueryImportSnapshot(id string) string
+	// QueryStatementTimeout returns a query limiting execution time of each following statement of a transaction
+	// on the database server. Returns an empty string if statements execution time cannot be limited by the server.
+	QueryStatementTimeout(timeout time.Duration) string
+}
+
+// Querier executes queries. Implemented by [sql.DB] and [sql.Tx].
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
 // Database holds database object attributes.
@@ -28,17 +66,98 @@ type Database struct {
 	Handler *sql.DB
 	// URI is a connection string or a data file path.
 	URI string
+	// Label identifies the database in output: its user-supplied alias, if any, or its identification string
+	// with credentials redacted.
+	Label string
+	// StatementTimeout limits each query execution time. Zero means no limit. It is applied by the database server
+	// to statements executed in Tx, if supported, see [DatabaseType.QueryStatementTimeout]. Otherwise, queries are
+	// canceled if their first rows are not available in time, so that rows retrieval paced by the caller is not limited.
+	StatementTimeout time.Duration
+	// Tx is a transaction database reads are performed in, if any.
+	Tx *sql.Tx
+	// Snapshot is an identifier of Tx snapshot exported to be shared with other transactions, if any.
+	Snapshot string
+}
+
+// Querier returns the database transaction, if any, or the database handler.
+func (d *Database) Querier() Querier {
+	if d.Tx != nil {
+		return d.Tx
+	}
+	return d.Handler
+}
+
+// QueryContext executes a query returning rows. The query is canceled on context cancellation or statement timeout
+// expiration. The returned function must be called once the rows are no longer used.
+func (d *Database) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := d.startStatementTimer(cancel)
+	rows, err := d.Querier().QueryContext(ctx, query, args...)
+	stop()
+	if err != nil {
+		cancel()
+		return nil, func() {}, err
+	}
+	return rows, cancel, nil
+}
+
+// ScanRow executes a query with given parameters returning at most one row and copies its columns into dest.
+// The query is canceled on context cancellation or statement timeout expiration.
+func (d *Database) ScanRow(ctx context.Context, query string, args []any, dest ...any) error {
+	ctx, cancel := d.statementContext(ctx)
+	defer cancel()
+	return d.Querier().QueryRowContext(ctx, query, args...).Scan(dest...)
+}
+
+// statementContext returns a copy of context limited by statement timeout, if any and not applied by the server.
+func (d *Database) statementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d.StatementTimeout > 0 && !d.serverStatementTimeout() {
+		return context.WithTimeout(ctx, d.StatementTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// startStatementTimer starts a timer calling a given function on statement timeout expiration, if any and not applied
+// by the server. The returned function stops the timer.
+func (d *Database) startStatementTimer(cancel context.CancelFunc) func() {
+	if d.StatementTimeout <= 0 || d.serverStatementTimeout() {
+		return func() {}
+	}
+	timer := time.AfterFunc(d.StatementTimeout, cancel)
+	return func() { timer.Stop() }
+}
+
+// serverStatementTimeout reports whether the statement timeout is applied by the database server.
+func (d *Database) serverStatementTimeout() bool {
+	return d.Tx != nil && len(d.DBType.QueryStatementTimeout(d.StatementTimeout)) > 0
+}
+
+// limitStatements limits execution time of statements of a given transaction on the database server, if supported.
+func (d *Database) limitStatements(ctx context.Context, tx *sql.Tx) error {
+	if d.StatementTimeout <= 0 {
+		return nil
+	}
+	if query := d.DBType.QueryStatementTimeout(d.StatementTimeout); len(query) > 0 {
+		_, err := tx.ExecContext(ctx, query)
+		return err
+	}
+	return nil
 }
 
 // FetchDataRowFromTable fetches a row for a given table primary key value.
-func (d *Database) FetchDataRowFromTable(table *Table, name string, data []any) error {
-	return d.Handler.QueryRow(
+// The row is fetched only if it satisfies table predicate, if any.
+func (d *Database) FetchDataRowFromTable(ctx context.Context, table *Table, name string, data []any) error {
+	return d.ScanRow(
+		ctx,
 		fmt.Sprintf(
-			"SELECT %s FROM %s WHERE %s = '%s';",
-			table.FieldsSQL(),
-			table.Name,
-			table.PrimaryKey.Name,
-			name,
+			"SELECT %s FROM %s WHERE %s = %s%s;",
+			table.FieldsSQL(d.DBType),
+			d.DBType.QuoteIdentifier(table.Name),
+			d.DBType.QuoteIdentifier(table.PrimaryKey.Name),
+			d.DBType.Placeholder(1),
+			table.predicateSuffix(),
 		),
-	).Scan(data...)
+		[]any{name},
+		data...,
+	)
 }
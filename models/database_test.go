@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseStatementContext(t *testing.T) {
+	var tests = []struct {
+		name             string
+		statementTimeout time.Duration
+		tx               *sql.Tx
+		serverTimeout    string
+		expectedDeadline bool
+	}{
+		{"no_timeout", 0, nil, "", false},
+		{"timeout", time.Minute, nil, "", true},
+		{"no_transaction", time.Minute, nil, "SET LOCAL statement_timeout = 60000;", true},
+		{"server_timeout", time.Minute, &sql.Tx{}, "SET LOCAL statement_timeout = 60000;", false},
+		{"client_timeout", time.Minute, &sql.Tx{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Database{
+				DBType:           mockTimeoutDatabaseType{query: tt.serverTimeout},
+				Tx:               tt.tx,
+				StatementTimeout: tt.statementTimeout,
+			}
+			ctx, cancel := d.statementContext(context.Background())
+			_, actualDeadline := ctx.Deadline()
+			require.Equal(t, tt.expectedDeadline, actualDeadline)
+
+			cancel()
+			require.ErrorIs(t, ctx.Err(), context.Canceled)
+		})
+	}
+}
+
+func TestDatabaseStartStatementTimer(t *testing.T) {
+	var tests = []struct {
+		name             string
+		statementTimeout time.Duration
+		tx               *sql.Tx
+		serverTimeout    string
+		expectedCanceled bool
+	}{
+		{"no_timeout", 0, nil, "", false},
+		{"timeout", time.Millisecond, nil, "", true},
+		{"server_timeout", time.Millisecond, &sql.Tx{}, "SET LOCAL statement_timeout = 1;", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Database{
+				DBType:           mockTimeoutDatabaseType{query: tt.serverTimeout},
+				Tx:               tt.tx,
+				StatementTimeout: tt.statementTimeout,
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stop := d.startStatementTimer(cancel)
+			time.Sleep(10 * time.Millisecond)
+			stop()
+			require.Equal(t, tt.expectedCanceled, ctx.Err() != nil)
+		})
+	}
+}
+
+func TestDatabaseStopStatementTimer(t *testing.T) {
+	d := Database{DBType: mockTimeoutDatabaseType{}, StatementTimeout: 10 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.startStatementTimer(cancel)()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, ctx.Err())
+}
+
+// mockTimeoutDatabaseType is a database type returning a given statement timeout query. Other methods are not implemented.
+type mockTimeoutDatabaseType struct {
+	DatabaseType
+	query string
+}
+
+func (m mockTimeoutDatabaseType) QueryStatementTimeout(time.Duration) string {
+	return m.query
+}
@@ -1,5 +1,7 @@
 package models
 
+import "strings"
+
 // Field holds field object attributes.
 type Field struct {
 	Name       string
@@ -7,4 +9,23 @@ type Field struct {
 	PrimaryKey bool
 	// Attrs holds field object attributes. Attributes differ in databases of different types.
 	Attrs string
+	// NotNull reports whether field is declared NOT NULL.
+	NotNull bool
+}
+
+// ValueConverter converts a non-NULL raw value of a given field into its representation used in comparison.
+type ValueConverter func(field *Field, value string) string
+
+// Canonicalizers maps field types to converters of their values into canonical representations.
+type Canonicalizers map[string]ValueConverter
+
+// Canonicalize converts a non-NULL raw value of a given field with a converter registered for the field type.
+// Field type modifiers, e.g. length, are not taken into account. Values of field types without registered converters
+// are returned as is.
+func (c Canonicalizers) Canonicalize(field *Field, value string) string {
+	fieldType, _, _ := strings.Cut(field.FieldType, "(")
+	if convert, ok := c[fieldType]; ok {
+		return convert(field, value)
+	}
+	return value
 }
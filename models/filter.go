@@ -0,0 +1,90 @@
+package models
+
+import (
+	"path"
+	"strings"
+)
+
+// ColumnFilter holds glob patterns of table columns excluded from comparison.
+// A pattern is either a column name pattern applied to all tables, e.g. "updated_at" or "*_at",
+// or a table-scoped one, where table and column patterns are separated by a dot, e.g. "orders.updated_at".
+type ColumnFilter struct {
+	Patterns []string
+}
+
+// NewColumnFilter returns a new ColumnFilter object for given patterns. Empty patterns are skipped.
+func NewColumnFilter(patterns []string) *ColumnFilter {
+	return &ColumnFilter{Patterns: trimPatterns(patterns)}
+}
+
+// Match reports whether given table column matches any of the filter patterns.
+// Table and column names are matched case-insensitively, see [GlobMatch].
+func (cf *ColumnFilter) Match(table, column string) bool {
+	if cf == nil {
+		return false
+	}
+	for _, p := range cf.Patterns {
+		tablePattern, columnPattern, scoped := strings.Cut(p, ".")
+		if !scoped {
+			tablePattern, columnPattern = "*", p
+		}
+		if GlobMatch(tablePattern, table) && GlobMatch(columnPattern, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimPatterns returns given patterns with surrounding spaces removed and empty ones skipped.
+func trimPatterns(patterns []string) []string {
+	var trimmed []string
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); len(p) > 0 {
+			trimmed = append(trimmed, p)
+		}
+	}
+	return trimmed
+}
+
+// GlobMatch reports whether name matches shell pattern. Names are matched case-insensitively, as both SQLite and
+// PostgreSQL unquoted identifiers are. Malformed patterns never match.
+func GlobMatch(pattern, name string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return err == nil && matched
+}
+
+// TableFilter holds glob patterns of tables included into and excluded from comparison.
+// If no include patterns are specified, all the tables are included.
+type TableFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// NewTableFilter returns a new TableFilter object for given include and exclude patterns. Empty patterns are skipped.
+func NewTableFilter(include, exclude []string) *TableFilter {
+	return &TableFilter{Include: trimPatterns(include), Exclude: trimPatterns(exclude)}
+}
+
+// Match reports whether a table with given name passes the filter. Names are matched case-insensitively,
+// see [GlobMatch].
+func (tf *TableFilter) Match(name string) bool {
+	if tf == nil {
+		return true
+	}
+	included := len(tf.Include) == 0
+	for _, p := range tf.Include {
+		if GlobMatch(p, name) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, p := range tf.Exclude {
+		if GlobMatch(p, name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnFilterMatch(t *testing.T) {
+	var tests = []struct {
+		name     string
+		patterns []string
+		table    string
+		column   string
+		expected bool
+	}{
+		{"no_patterns", nil, "orders", "updated_at", false},
+		{"exact", []string{"updated_at"}, "orders", "updated_at", true},
+		{"exact_other", []string{"updated_at"}, "orders", "created_at", false},
+		{"glob", []string{"*_at"}, "orders", "synced_at", true},
+		{"case_insensitive", []string{"Updated_At"}, "orders", "updated_at", true},
+		{"case_insensitive_name", []string{"orders.username"}, "Orders", "UserName", true},
+		{"scoped", []string{"orders.version"}, "orders", "version", true},
+		{"scoped_other_table", []string{"orders.version"}, "items", "version", false},
+		{"scoped_glob", []string{"audit_*.*"}, "audit_log", "payload", true},
+		{"empty_pattern", []string{" "}, "orders", "version", false},
+		{"malformed", []string{"[version"}, "orders", "version", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, NewColumnFilter(tt.patterns).Match(tt.table, tt.column))
+		})
+	}
+}
+
+func TestTableIgnoreFields(t *testing.T) {
+	id := Field{Name: "id", FieldType: "integer", PrimaryKey: true}
+	name := Field{Name: "name", FieldType: "text"}
+	updatedAt := Field{Name: "updated_at", FieldType: "timestamp"}
+	table := Table{
+		Name:           "orders",
+		Fields:         []*Field{&id, &updatedAt, &name},
+		FieldNameIndex: map[string]int{"id": 0, "updated_at": 1, "name": 2},
+	}
+
+	table.IgnoreFields(NewColumnFilter([]string{"orders.updated_at"}))
+
+	require.Equal(t, []*Field{&id, &name}, table.Fields)
+	require.Equal(t, []*Field{&updatedAt}, table.IgnoredFields)
+	require.Equal(t, map[string]int{"id": 0, "name": 1}, table.FieldNameIndex)
+	require.Equal(t, `"id", "name"`, table.FieldsSQL(mockDatabaseType{}))
+}
+
+func TestTableIgnoreFieldsNamed(t *testing.T) {
+	id := Field{Name: "id", FieldType: "integer", PrimaryKey: true}
+	name := Field{Name: "name", FieldType: "text"}
+	table := Table{
+		Name:           "orders",
+		Fields:         []*Field{&id, &name},
+		FieldNameIndex: map[string]int{"id": 0, "name": 1},
+	}
+
+	table.IgnoreFieldsNamed([]string{"name", "missing"})
+
+	require.Equal(t, []*Field{&id}, table.Fields)
+	require.Equal(t, []*Field{&name}, table.IgnoredFields)
+	require.Equal(t, map[string]int{"id": 0}, table.FieldNameIndex)
+}
+
+// mockDatabaseType is a database type quoting identifiers in double quotes. Other methods are not implemented.
+type mockDatabaseType struct {
+	DatabaseType
+}
+
+func (mockDatabaseType) QuoteIdentifier(name string) string {
+	return `"` + name + `"`
+}
+
+func TestTableFilterMatch(t *testing.T) {
+	var tests = []struct {
+		name     string
+		include  []string
+		exclude  []string
+		table    string
+		expected bool
+	}{
+		{"no_patterns", nil, nil, "orders", true},
+		{"included", []string{"ord*"}, nil, "orders", true},
+		{"not_included", []string{"items"}, nil, "orders", false},
+		{"excluded", nil, []string{"audit_*"}, "audit_log", false},
+		{"not_excluded", nil, []string{"audit_*"}, "orders", true},
+		{"included_and_excluded", []string{"*"}, []string{"orders"}, "orders", false},
+		{"included_case_insensitive", []string{"Ord*"}, nil, "ORDERS", true},
+		{"excluded_case_insensitive", nil, []string{"audit_*"}, "Audit_Log", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, NewTableFilter(tt.include, tt.exclude).Match(tt.table))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Index holds table index attributes.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	// Constraint indicates that the index implements a unique constraint.
+	Constraint bool
+}
+
+// Definition returns index definition, which does not depend on index name, e.g. "unique (id, name)".
+// Indexes with equal definitions are interchangeable.
+func (i *Index) Definition() string {
+	var unique string
+	if i.Unique {
+		unique = "unique "
+	}
+	return fmt.Sprintf("%s(%s)", unique, strings.Join(i.Columns, ", "))
+}
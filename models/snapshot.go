@@ -0,0 +1,62 @@
+package models
+
+import (
+	"context"
+)
+
+// BeginSnapshot starts a transaction all the database reads are performed in, so that they observe a consistent
+// snapshot of data. If supported by the database type, the transaction snapshot is exported to be shared
+// with other transactions, see [Database.JoinSnapshot], and the statement timeout is applied by the server.
+func (d *Database) BeginSnapshot(ctx context.Context) error {
+	tx, err := d.Handler.BeginTx(ctx, d.DBType.TxOptions())
+	if err != nil {
+		return err
+	}
+	if query := d.DBType.QueryExportSnapshot(); len(query) > 0 {
+		if err = tx.QueryRowContext(ctx, query).Scan(&d.Snapshot); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err = d.limitStatements(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	d.Tx = tx
+	return nil
+}
+
+// JoinSnapshot returns a copy of the database performing reads in a separate transaction importing the exported
+// database snapshot, so that reads can be performed concurrently. If the snapshot is not exported, the copy shares
+// the database transaction. The returned function must be called once the copy is no longer used.
+func (d *Database) JoinSnapshot(ctx context.Context) (Database, func(), error) {
+	joined := *d
+	if d.Tx == nil || len(d.Snapshot) == 0 {
+		return joined, func() {}, nil
+	}
+
+	tx, err := d.Handler.BeginTx(ctx, d.DBType.TxOptions())
+	if err != nil {
+		return joined, func() {}, err
+	}
+	if _, err = tx.ExecContext(ctx, d.DBType.QueryImportSnapshot(d.Snapshot)); err != nil {
+		_ = tx.Rollback()
+		return joined, func() {}, err
+	}
+	// the snapshot is imported first, as required by PostgreSQL
+	if err = d.limitStatements(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return joined, func() {}, err
+	}
+	joined.Tx = tx
+	return joined, func() { _ = tx.Rollback() }, nil
+}
+
+// EndSnapshot ends the transaction database reads are performed in. Nothing is written in the transaction,
+// so that it is rolled back.
+func (d *Database) EndSnapshot() {
+	if d.Tx != nil {
+		_ = d.Tx.Rollback()
+		d.Tx = nil
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"sort"
@@ -13,9 +14,19 @@ type Table struct {
 	Schema     string
 	PrimaryKey *Field
 	Fields     []*Field
+	// IgnoredFields holds fields excluded from comparison by a column filter.
+	IgnoredFields []*Field
 	// FieldNameIndex is a map of field name to its index in fields slice.
 	FieldNameIndex map[string]int
 	DB             *Database
+	// Predicate is an optional SQL condition restricting compared table rows.
+	Predicate string
+	// Size is a number of table rows or its estimate. Used for scheduling tables data comparison.
+	Size int64
+	// Indexes holds table indexes, except the primary key one. Retrieved only if needed.
+	Indexes []*Index
+	// References holds names of tables referenced by table foreign keys. Retrieved only if needed.
+	References []string
 	// ComparisonResult is a cumulative result of table comparison in two databases.
 	ComparisonResult string
 }
@@ -35,9 +46,10 @@ func (t *Table) GetFieldsFromRow(row *sql.Rows) error {
 }
 
 // GetFields gets given table fields.
-func (t *Table) GetFields(name string) error {
+func (t *Table) GetFields(ctx context.Context, name string) error {
 	optionalSQL := new(sql.NullString)
-	if err := t.DB.Handler.QueryRow(t.DB.DBType.QueryOne(name)).Scan(optionalSQL); err != nil {
+	query, args := t.DB.DBType.QueryOne(name)
+	if err := t.DB.ScanRow(ctx, query, args, optionalSQL); err != nil {
 		return err
 	}
 	if optionalSQL.Valid {
@@ -46,17 +58,74 @@ func (t *Table) GetFields(name string) error {
 		// postgres driver does not return this error if main query returns empty result
 		return sql.ErrNoRows
 	}
+	t.Name = name
 	t.DB.DBType.Parse(t)
 	return nil
 }
 
-// AddField adds a field to table by parsing given raw string.
+// GetSize gets table number of rows or its estimate.
+func (t *Table) GetSize(ctx context.Context) error {
+	query, args := t.DB.DBType.QuerySize(t.Name)
+	return t.DB.ScanRow(ctx, query, args, &t.Size)
+}
+
+// GetIndexes gets table indexes.
+func (t *Table) GetIndexes(ctx context.Context) error {
+	query, args := t.DB.DBType.QueryIndexes(t.Name)
+	rows, cancel, err := t.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer rows.Close()
+
+	t.Indexes = nil
+	for rows.Next() {
+		var (
+			index   Index
+			columns string
+		)
+		if err = rows.Scan(&index.Name, &index.Unique, &index.Constraint, &columns); err != nil {
+			return err
+		}
+		index.Columns = strings.Split(columns, ",")
+		t.Indexes = append(t.Indexes, &index)
+	}
+	return rows.Err()
+}
+
+// GetReferences gets names of tables referenced by table foreign keys.
+func (t *Table) GetReferences(ctx context.Context) error {
+	query, args := t.DB.DBType.QueryReferences(t.Name)
+	rows, cancel, err := t.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer rows.Close()
+
+	t.References = nil
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return err
+		}
+		t.References = append(t.References, name)
+	}
+	return rows.Err()
+}
+
+// AddField adds a field to table by parsing given raw string. Quotes enclosing field name are removed.
+// Field name case is kept, while field type and attributes are lowercased.
 func (t *Table) AddField(rawField string) {
-	attrs := strings.Split(rawField, " ")
+	name, definition, _ := strings.Cut(rawField, " ")
+	definition = strings.ToLower(definition)
+	attrs := append([]string{strings.Trim(name, "\"`[]")}, strings.Split(definition, " ")...)
 	f := Field{
 		Name:       attrs[0],
 		FieldType:  attrs[1],
-		PrimaryKey: strings.Contains(rawField, "primary key"),
+		PrimaryKey: strings.Contains(definition, "primary key"),
+		NotNull:    strings.Contains(definition, "not null"),
 	}
 	if len(attrs) > 2 {
 		f.Attrs = strings.Join(attrs[2:], " ")
@@ -69,6 +138,44 @@ func (t *Table) AddField(rawField string) {
 	t.FieldNameIndex[attrs[0]] = i
 }
 
+// IgnoreFields moves fields matching given column filter from table fields to ignored fields.
+// Ignored fields are neither compared, nor included in table data queries.
+func (t *Table) IgnoreFields(filter *ColumnFilter) {
+	t.ignoreFields(func(f *Field) bool { return filter.Match(t.Name, f.Name) })
+}
+
+// IgnoreFieldsNamed moves fields with given names from table fields to ignored fields.
+func (t *Table) IgnoreFieldsNamed(names []string) {
+	t.ignoreFields(func(f *Field) bool {
+		for _, name := range names {
+			if f.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ignoreFields moves fields matching a given function from table fields to ignored fields.
+func (t *Table) ignoreFields(match func(f *Field) bool) {
+	fields := make([]*Field, 0, len(t.Fields))
+	for _, f := range t.Fields {
+		if match(f) {
+			t.IgnoredFields = append(t.IgnoredFields, f)
+		} else {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == len(t.Fields) {
+		return
+	}
+	t.Fields = fields
+	t.FieldNameIndex = make(map[string]int, len(fields))
+	for i, f := range fields {
+		t.FieldNameIndex[f.Name] = i
+	}
+}
+
 // SortFields sorts table fields in alphabetical order.
 func (t *Table) SortFields() {
 	if !sort.SliceIsSorted(
@@ -82,45 +189,60 @@ func (t *Table) SortFields() {
 	}
 }
 
-// FieldsSQL returns concatenated table fields names sorted in alphabetical order.
-func (t *Table) FieldsSQL() string {
+// FieldsSQL returns concatenated table fields names sorted in alphabetical order and quoted according to a given
+// database type. Ignored fields are not included.
+func (t *Table) FieldsSQL(dbType DatabaseType) string {
 	var s string
 	t.SortFields()
 	for i, f := range t.Fields {
 		if i == 0 {
-			s += f.Name
+			s += dbType.QuoteIdentifier(f.Name)
 		} else {
-			s += ", " + f.Name
+			s += ", " + dbType.QuoteIdentifier(f.Name)
 		}
 	}
 	return s
 }
 
-// ParseRawSQLValues converts retrieved from database raw values of type NullString (optional strings) into string ones.
-// Slice of input raw values corresponds to the table one row. Length of input raw values must be equal to the length of the output string values
-// and the number of table fields. Order of fields in the input raw values slice must be the same as in the output string values one and must correspond to
+// ParseRawSQLValues converts retrieved from database raw values of type NullString (optional strings) into optional string values.
+// Slice of input raw values corresponds to the table one row. Length of input raw values must be equal to the length of the output values
+// and the number of table fields. Order of fields in the input raw values slice must be the same as in the output values one and must correspond to
 // the table alphabetically ordered field names.
-// Values of fields of type boolean are transformed to lower case.
-func (t *Table) ParseRawSQLValues(rawValues *[]any, values *[]string) {
+// NULL values are kept invalid, so that they can be distinguished from empty strings.
+// Non-NULL values are converted with a given converter, if any.
+func (t *Table) ParseRawSQLValues(rawValues *[]any, values *[]sql.NullString, convert ValueConverter) {
 	for i, el := range *rawValues {
-		if ns, ok := el.(*sql.NullString); ok && ns.Valid {
-			(*values)[i] = ns.String
-			if t.Fields[i].FieldType == "boolean" {
-				(*values)[i] = strings.ToLower((*values)[i])
+		if ns, ok := el.(*sql.NullString); ok {
+			(*values)[i] = *ns
+			if ns.Valid && convert != nil {
+				(*values)[i].String = convert(t.Fields[i], ns.String)
 			}
 		}
 	}
 }
 
-// QueryDataAll returns table data query string. The query retrieves table primary key field name followed by all the fields sorted in alphabetical order.
-func (t *Table) QueryDataAll() string {
-	return fmt.Sprintf("SELECT %s, %s FROM %s;", t.PrimaryKey.Name, t.FieldsSQL(), t.Name)
-}
-
-// QueryDataExcluded returns a query string for fetching table data excluding rows with the given primary key values.
-func (t *Table) QueryDataExcluded(epk *[]string) string {
+// QueryDataAll returns table data query string for a given database type. The query retrieves table primary key field
+// followed by all the fields sorted in alphabetical order. Only rows satisfying table predicate, if any, are retrieved.
+func (t *Table) QueryDataAll(dbType DatabaseType) string {
+	var where string
+	if len(t.Predicate) > 0 {
+		where = fmt.Sprintf(" WHERE %s", t.PredicateSQL())
+	}
 	return fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s NOT IN (%s);",
-		t.FieldsSQL(), t.Name, t.PrimaryKey.Name, strings.Join(*epk, ","),
+		"SELECT %s, %s FROM %s%s;",
+		dbType.QuoteIdentifier(t.PrimaryKey.Name), t.FieldsSQL(dbType), dbType.QuoteIdentifier(t.Name), where,
 	)
 }
+
+// PredicateSQL returns table predicate enclosed in parentheses, so that it can be safely combined with other conditions.
+func (t *Table) PredicateSQL() string {
+	return fmt.Sprintf("(%s)", t.Predicate)
+}
+
+// predicateSuffix returns table predicate prefixed with AND or an empty string if table has no predicate.
+func (t *Table) predicateSuffix() string {
+	if len(t.Predicate) == 0 {
+		return ""
+	}
+	return " AND " + t.PredicateSQL()
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableAddField(t *testing.T) {
+	var tests = []struct {
+		rawField      string
+		expectedField Field
+	}{
+		{"id integer primary key", Field{Name: "id", FieldType: "integer", PrimaryKey: true, Attrs: "primary key"}},
+		{`"order" text`, Field{Name: "order", FieldType: "text"}},
+		{"`select` text not null", Field{Name: "select", FieldType: "text", Attrs: "not null", NotNull: true}},
+		{"[from] text", Field{Name: "from", FieldType: "text"}},
+		{`"UserName" TEXT NOT NULL`, Field{Name: "UserName", FieldType: "text", Attrs: "not null", NotNull: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawField, func(t *testing.T) {
+			table := Table{FieldNameIndex: make(map[string]int)}
+			table.AddField(tt.rawField)
+			require.Equal(t, []*Field{&tt.expectedField}, table.Fields)
+			require.Equal(t, map[string]int{tt.expectedField.Name: 0}, table.FieldNameIndex)
+		})
+	}
+}
+
+func TestTableQueryDataAll(t *testing.T) {
+	id := Field{Name: "id", FieldType: "integer", PrimaryKey: true}
+	order := Field{Name: "order", FieldType: "text"}
+	table := Table{Name: "User", PrimaryKey: &id, Fields: []*Field{&order, &id}}
+
+	require.Equal(t, `SELECT "id", "id", "order" FROM "User";`, table.QueryDataAll(mockDatabaseType{}))
+
+	table.Predicate = "id > 1"
+	require.Equal(t, `SELECT "id", "id", "order" FROM "User" WHERE (id > 1);`, table.QueryDataAll(mockDatabaseType{}))
+}
@@ -94,7 +94,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsLeftTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_sqlite_right_table_absent_verbosity0",
@@ -103,7 +103,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsRightTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_sqlite_schema_different_verbosity0",
@@ -158,7 +158,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_postgres_right_table_absent_verbosity0",
@@ -167,7 +167,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_postgres_schema_different_verbosity0",
@@ -221,7 +221,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_sqlite_right_table_absent_verbosity0",
@@ -230,7 +230,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_sqlite_schema_different_verbosity0",
@@ -284,7 +284,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_postgres_right_table_absent_verbosity0",
@@ -293,7 +293,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV0Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_postgres_schema_different_verbosity0",
@@ -348,7 +348,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsLeftTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_sqlite_right_table_absent_verbosity1",
@@ -357,7 +357,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsRightTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_sqlite_schema_different_verbosity1",
@@ -414,7 +414,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_postgres_right_table_absent_verbosity1",
@@ -423,7 +423,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_postgres_schema_different_verbosity1",
@@ -479,7 +479,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_sqlite_right_table_absent_verbosity1",
@@ -488,7 +488,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_sqlite_schema_different_verbosity1",
@@ -544,7 +544,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_postgres_right_table_absent_verbosity1",
@@ -553,7 +553,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV1Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_postgres_schema_different_verbosity1",
@@ -617,7 +617,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsLeftTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_sqlite_right_table_absent_verbosity2",
@@ -626,7 +626,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsRightTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_sqlite_schema_different_verbosity2",
@@ -700,7 +700,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_postgres_right_table_absent_verbosity2",
@@ -709,7 +709,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_postgres_schema_different_verbosity2",
@@ -783,7 +783,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_sqlite_right_table_absent_verbosity2",
@@ -792,7 +792,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_sqlite_schema_different_verbosity2",
@@ -866,7 +866,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_postgres_right_table_absent_verbosity2",
@@ -875,7 +875,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV2Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_postgres_schema_different_verbosity2",
@@ -962,7 +962,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsLeftTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_sqlite_right_table_absent_verbosity3",
@@ -971,7 +971,7 @@ func TestCompareDatabases(t *testing.T) {
 			nil,
 			dbDiffsRightTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_sqlite_schema_different_verbosity3",
@@ -1065,7 +1065,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"sqlite_postgres_right_table_absent_verbosity3",
@@ -1074,7 +1074,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"sqlite_postgres_schema_different_verbosity3",
@@ -1168,7 +1168,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_sqlite_right_table_absent_verbosity3",
@@ -1177,7 +1177,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_sqlite_schema_different_verbosity3",
@@ -1271,7 +1271,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsLeftTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database1\n",
+			"Table table0: does not exist in Database1\n",
 		},
 		{
 			"postgres_postgres_right_table_absent_verbosity3",
@@ -1280,7 +1280,7 @@ func TestCompareDatabases(t *testing.T) {
 			testContainers{postgres1Container, postgres2Container},
 			dbDiffsRightTableAbsent1Table,
 			mockV3Ctx,
-			"Table table0: does not exist in database2\n",
+			"Table table0: does not exist in Database2\n",
 		},
 		{
 			"postgres_postgres_schema_different_verbosity3",
@@ -1354,8 +1354,11 @@ func TestCompareDatabases(t *testing.T) {
 			require.NoError(t, test.db1.initialize())
 			require.NoError(t, test.db2.initialize())
 
+			// aliases keep the expected output independent of the databases connection strings
 			done := captureOutput()
-			dbdiff.NewDatabaseComparer().Compare(test.context, test.db1.mockInputString(), test.db2.mockInputString())
+			dbdiff.NewDatabaseComparer().Compare(
+				test.context, "Database1="+test.db1.mockInputString(), "Database2="+test.db2.mockInputString(),
+			)
 			capturedOutput, err := done()
 			require.NoError(t, err)
 
@@ -1363,3 +1366,44 @@ func TestCompareDatabases(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncDatabases(t *testing.T) {
+	tests := []struct {
+		name          string
+		db1           mockDatabase
+		db2           mockDatabase
+		dbDifferences databaseDifferences
+	}{
+		{"sqlite_postgres_differences", mockSqliteDB1, mockPostgresDB2, dbDiffsMixed2Tables},
+		{"postgres_postgres_differences", mockPostgresDB1, mockPostgresDB2, dbDiffsMixed2Tables},
+		{"postgres_postgres_right_table_absent", mockPostgresDB1, mockPostgresDB2, dbDiffsRightTableAbsent1Table},
+		{"postgres_postgres_left_table_absent", mockPostgresDB1, mockPostgresDB2, dbDiffsLeftTableAbsent1Table},
+	}
+
+	postgresContainers := testContainers{postgres1Container, postgres2Container}
+
+	setUp(t, postgresContainers)
+	defer tearDown(t, postgresContainers)
+
+	// generated statements, including destructive ones, are applied to PostgreSQL database2
+	syncCtx := context.WithValue(ctx, dbdiff.AssumeYesContextKey, true)
+	syncCtx = context.WithValue(syncCtx, dbdiff.SchemaSQLDestructiveContextKey, true)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resetEnv(t, postgresContainers)
+
+			generateDatabases(test.dbDifferences, &test.db1, &test.db2)
+			require.NoError(t, test.db1.initialize())
+			require.NoError(t, test.db2.initialize())
+
+			done := captureOutput()
+			dbdiff.NewDatabaseSynchronizer().Sync(syncCtx, test.db1.mockInputString(), test.db2.mockInputString())
+			differ := dbdiff.NewDatabaseComparer().Compare(ctx, test.db1.mockInputString(), test.db2.mockInputString())
+			_, err := done()
+			require.NoError(t, err)
+
+			require.False(t, differ)
+		})
+	}
+}